@@ -8,11 +8,16 @@ CREATE TABLE IF NOT EXISTS "user" (
   ctime     BIGINT NOT NULL,
   utime     BIGINT NOT NULL,
   account   VARCHAR(20) UNIQUE NOT NULL,
-  password  VARCHAR(20) NOT NULL,
-  email     VARCHAR(20) UNIQUE NOT NULL,
+  password  VARCHAR(255) NOT NULL, -- argon2id 哈希
+  email     VARCHAR(254) UNIQUE NOT NULL,
   username  VARCHAR(20) NOT NULL,
   avatar    VARCHAR(255) NOT NULL,
-  role      SMALLINT NOT NULL DEFAULT 1
+  role      SMALLINT NOT NULL DEFAULT 1,
+  token_version INT NOT NULL DEFAULT 0,
+  locale    VARCHAR(10) NOT NULL DEFAULT '',
+  deleted_at BIGINT NOT NULL DEFAULT 0,
+  disabled  BOOLEAN NOT NULL DEFAULT FALSE,
+  must_reset_password BOOLEAN NOT NULL DEFAULT FALSE
 );
 
 COMMENT ON TABLE "user" IS '用户表';
@@ -21,8 +26,149 @@ COMMENT ON COLUMN "user".user_id IS '用户ID';
 COMMENT ON COLUMN "user".ctime IS '创建时间';
 COMMENT ON COLUMN "user".utime IS '更新时间';
 COMMENT ON COLUMN "user".account IS '账号';
-COMMENT ON COLUMN "user".password IS '密码';
+COMMENT ON COLUMN "user".password IS '密码（argon2id 哈希）';
 COMMENT ON COLUMN "user".email IS '邮箱';
 COMMENT ON COLUMN "user".username IS '用户名';
 COMMENT ON COLUMN "user".avatar IS '头像';
-COMMENT ON COLUMN "user".role IS '角色';
\ No newline at end of file
+COMMENT ON COLUMN "user".role IS '角色';
+COMMENT ON COLUMN "user".token_version IS '令牌版本，修改密码时递增使已签发的令牌失效';
+COMMENT ON COLUMN "user".locale IS '偏好语言，例如 zh-CN、en-US，为空时按请求的 Accept-Language';
+COMMENT ON COLUMN "user".deleted_at IS '申请注销的时间，0 表示未注销，宽限期过后由后台任务彻底删除';
+COMMENT ON COLUMN "user".disabled IS '是否被管理员禁用，禁用后无法登录';
+COMMENT ON COLUMN "user".must_reset_password IS '是否被管理员要求重置密码，重置前无法登录';
+
+-- 3. 存量数据升级
+-- 密码列改为存储 argon2id 哈希，需要更长的长度
+ALTER TABLE "user" ALTER COLUMN password TYPE VARCHAR(255);
+-- 令牌版本
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
+-- 邮箱列放宽到 RFC 5321 允许的最大长度
+ALTER TABLE "user" ALTER COLUMN email TYPE VARCHAR(254);
+-- 偏好语言
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT '';
+-- 账号注销
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS deleted_at BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS user_deleted_at_idx ON "user" (deleted_at) WHERE deleted_at > 0;
+-- 管理员禁用账号和要求重置密码
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS must_reset_password BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- 4. 刷新令牌表，同一次登录派生出的令牌属于同一个 family
+CREATE TABLE IF NOT EXISTS refresh_token (
+  id          BIGSERIAL PRIMARY KEY,
+  token_hash  CHAR(64) UNIQUE NOT NULL,
+  family_id   UUID NOT NULL,
+  user_id     UUID NOT NULL REFERENCES "user" (user_id) ON DELETE CASCADE,
+  ctime       BIGINT NOT NULL,
+  expire_time BIGINT NOT NULL,
+  used        BOOLEAN NOT NULL DEFAULT FALSE,
+  revoked     BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_token_family_id_idx ON refresh_token (family_id);
+CREATE INDEX IF NOT EXISTS refresh_token_user_id_idx ON refresh_token (user_id);
+
+COMMENT ON TABLE refresh_token IS '刷新令牌表';
+COMMENT ON COLUMN refresh_token.id IS '主键ID';
+COMMENT ON COLUMN refresh_token.token_hash IS '令牌的 SHA-256 摘要，不保存明文';
+COMMENT ON COLUMN refresh_token.family_id IS '令牌家族ID，轮换出的新令牌沿用同一个';
+COMMENT ON COLUMN refresh_token.user_id IS '用户ID';
+COMMENT ON COLUMN refresh_token.ctime IS '创建时间';
+COMMENT ON COLUMN refresh_token.expire_time IS '过期时间';
+COMMENT ON COLUMN refresh_token.used IS '是否已被轮换';
+COMMENT ON COLUMN refresh_token.revoked IS '是否已被吊销';
+
+-- 5. 登录会话表，会话ID与刷新令牌的 family_id 一致
+CREATE TABLE IF NOT EXISTS user_session (
+  id          BIGSERIAL PRIMARY KEY,
+  session_id  UUID UNIQUE NOT NULL,
+  user_id     UUID NOT NULL REFERENCES "user" (user_id) ON DELETE CASCADE,
+  user_agent  VARCHAR(255) NOT NULL,
+  ip          VARCHAR(64) NOT NULL,
+  ctime       BIGINT NOT NULL,
+  last_seen   BIGINT NOT NULL,
+  expire_time BIGINT NOT NULL,
+  revoked     BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS user_session_user_id_idx ON user_session (user_id);
+
+COMMENT ON TABLE user_session IS '登录会话表';
+COMMENT ON COLUMN user_session.id IS '主键ID';
+COMMENT ON COLUMN user_session.session_id IS '会话ID';
+COMMENT ON COLUMN user_session.user_id IS '用户ID';
+COMMENT ON COLUMN user_session.user_agent IS '登录设备的 User-Agent';
+COMMENT ON COLUMN user_session.ip IS '最近一次访问的IP';
+COMMENT ON COLUMN user_session.ctime IS '创建时间';
+COMMENT ON COLUMN user_session.last_seen IS '最近一次访问时间';
+COMMENT ON COLUMN user_session.expire_time IS '过期时间，随刷新令牌轮换延长';
+COMMENT ON COLUMN user_session.revoked IS '是否已被注销';
+
+-- 6. 邮件发件箱，邮件先落库再由后台 worker 异步投递，失败按指数退避重试，超过次数后进入死信状态
+CREATE TABLE IF NOT EXISTS email_outbox (
+  id              BIGSERIAL PRIMARY KEY,
+  message_id      UUID UNIQUE NOT NULL,
+  user_id         UUID,
+  recipient       VARCHAR(254) NOT NULL,
+  template        VARCHAR(64) NOT NULL,
+  locale          VARCHAR(10) NOT NULL,
+  subject         TEXT NOT NULL,
+  html            TEXT NOT NULL,
+  text            TEXT NOT NULL,
+  status          SMALLINT NOT NULL DEFAULT 0,
+  attempts        INT NOT NULL DEFAULT 0,
+  next_attempt_at BIGINT NOT NULL,
+  locked_until    BIGINT NOT NULL DEFAULT 0,
+  last_error      TEXT NOT NULL DEFAULT '',
+  expire_at       BIGINT NOT NULL DEFAULT 0,
+  ctime           BIGINT NOT NULL,
+  utime           BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS email_outbox_pending_idx ON email_outbox (next_attempt_at) WHERE status IN (0, 1);
+-- 存量数据升级：邮件归属的用户和过期时间
+ALTER TABLE email_outbox ADD COLUMN IF NOT EXISTS user_id UUID;
+ALTER TABLE email_outbox ADD COLUMN IF NOT EXISTS expire_at BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON TABLE email_outbox IS '邮件发件箱';
+COMMENT ON COLUMN email_outbox.id IS '主键ID';
+COMMENT ON COLUMN email_outbox.message_id IS '邮件ID，用于查询投递状态';
+COMMENT ON COLUMN email_outbox.user_id IS '发起发送的用户，未登录时发送的邮件为空';
+COMMENT ON COLUMN email_outbox.recipient IS '收件人';
+COMMENT ON COLUMN email_outbox.template IS '邮件模板名';
+COMMENT ON COLUMN email_outbox.locale IS '邮件语言';
+COMMENT ON COLUMN email_outbox.subject IS '邮件主题';
+COMMENT ON COLUMN email_outbox.html IS 'HTML 正文，投递成功或进入死信后清空';
+COMMENT ON COLUMN email_outbox.text IS '纯文本正文，投递成功或进入死信后清空';
+COMMENT ON COLUMN email_outbox.status IS '状态：0 待投递，1 投递中，2 已投递，3 死信';
+COMMENT ON COLUMN email_outbox.attempts IS '已尝试投递的次数';
+COMMENT ON COLUMN email_outbox.next_attempt_at IS '下一次投递时间';
+COMMENT ON COLUMN email_outbox.locked_until IS '投递中的租约到期时间，到期仍未完成时重新投递';
+COMMENT ON COLUMN email_outbox.last_error IS '最近一次投递失败的原因';
+COMMENT ON COLUMN email_outbox.expire_at IS '过期时间，过期仍未投递的邮件直接进入死信，0 表示不过期';
+COMMENT ON COLUMN email_outbox.ctime IS '创建时间';
+COMMENT ON COLUMN email_outbox.utime IS '更新时间';
+
+-- 7. 邮箱修改记录，修改后向旧邮箱发送撤销链接，在有效期内可以通过链接改回旧邮箱
+CREATE TABLE IF NOT EXISTS email_change (
+  id          BIGSERIAL PRIMARY KEY,
+  user_id     UUID NOT NULL REFERENCES "user" (user_id) ON DELETE CASCADE,
+  old_email   VARCHAR(254) NOT NULL,
+  new_email   VARCHAR(254) NOT NULL,
+  revert_hash CHAR(64) UNIQUE NOT NULL,
+  ctime       BIGINT NOT NULL,
+  expire_time BIGINT NOT NULL,
+  reverted    BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS email_change_user_id_idx ON email_change (user_id);
+
+COMMENT ON TABLE email_change IS '邮箱修改记录';
+COMMENT ON COLUMN email_change.id IS '主键ID';
+COMMENT ON COLUMN email_change.user_id IS '用户ID';
+COMMENT ON COLUMN email_change.old_email IS '修改前的邮箱';
+COMMENT ON COLUMN email_change.new_email IS '修改后的邮箱';
+COMMENT ON COLUMN email_change.revert_hash IS '撤销令牌的 SHA-256 摘要，不保存明文';
+COMMENT ON COLUMN email_change.ctime IS '创建时间';
+COMMENT ON COLUMN email_change.expire_time IS '撤销链接的过期时间';
+COMMENT ON COLUMN email_change.reverted IS '是否已被撤销';
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"nurture/internal/constant"
+	"time"
 )
 
 var Conf = new(Config)
@@ -12,19 +14,59 @@ type Config struct {
 	Redis Redis `mapstructure:"redis"`
 	Auth  Auth  `mapstructure:"auth"`
 	Email Email `mapstructure:"email"`
+	// 头像等用户上传文件的存储
+	Storage Storage `mapstructure:"storage"`
+	// 链路追踪
+	Telemetry Telemetry `mapstructure:"telemetry"`
+	// Prometheus 指标
+	Metrics Metrics `mapstructure:"metrics"`
 }
 
 type App struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
-	Env  string `mapstructure:"env"`
-	Log  string `mapstructure:"log"`
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Env      string `mapstructure:"env"`
+	Log      string `mapstructure:"log"`
+	CodeEcho bool   `mapstructure:"code_echo"` // 验证码回显，仅在 dev 环境下生效
+	// 可信的反向代理地址（IP 或 CIDR），只有来自这些地址的 X-Forwarded-For 才会被采信
+	// 为空时客户端 IP 取连接的对端地址，防止伪造请求头绕过按 IP 的限流
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// HTTP 服务的超时时间，单位秒，零值使用默认值
+	ReadTimeout  int64 `mapstructure:"read_timeout"`
+	WriteTimeout int64 `mapstructure:"write_timeout"`
+	IdleTimeout  int64 `mapstructure:"idle_timeout"`
+	// 收到退出信号后等待处理中的请求和后台任务完成的最长时间，单位秒
+	ShutdownTimeout int64 `mapstructure:"shutdown_timeout"`
 }
 
 func (app *App) Link() string {
 	return fmt.Sprintf("%s:%d", app.Host, app.Port)
 }
 
+// ServerTimeouts 返回 HTTP 服务的读、写和空闲超时时间，未配置时使用默认值
+func (app *App) ServerTimeouts() (read, write, idle time.Duration) {
+	return seconds(app.ReadTimeout, constant.SERVER_READ_TIMEOUT),
+		seconds(app.WriteTimeout, constant.SERVER_WRITE_TIMEOUT),
+		seconds(app.IdleTimeout, constant.SERVER_IDLE_TIMEOUT)
+}
+
+// ShutdownWait 返回退出时的最长等待时间，未配置时使用默认值
+func (app *App) ShutdownWait() time.Duration {
+	return seconds(app.ShutdownTimeout, constant.SERVER_SHUTDOWN_TIMEOUT)
+}
+
+func seconds(v int64, def time.Duration) time.Duration {
+	if v <= 0 {
+		return def
+	}
+	return time.Duration(v) * time.Second
+}
+
+// DevEcho 是否处于验证码回显模式：不真正发送邮件，直接在接口中返回验证码，方便前端联调
+func (app *App) DevEcho() bool {
+	return app.Env == "dev" && app.CodeEcho
+}
+
 type DB struct {
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
@@ -60,8 +102,43 @@ func (redis *Redis) DSN() string {
 
 // JWT 认证需要的密钥和过期时间配置
 type Auth struct {
-	AccessSecret string `json:"access_secret"`
-	AccessExpire int64  `json:"access_expire"`
+	AccessSecret  string `json:"access_secret"`
+	AccessExpire  int64  `json:"access_expire"`
+	RefreshExpire int64  `json:"refresh_expire"` // 刷新令牌有效期，单位秒
+	Argon2        Argon2 `mapstructure:"argon2"`
+	// 注册和重置密码时的密码强度要求
+	PasswordPolicy PasswordPolicy `mapstructure:"password_policy"`
+	// 非对称签名密钥，配置后使用 ActiveKid 对应的密钥签发令牌，其余密钥只用于校验
+	// 不配置时使用 HS256 + AccessSecret
+	ActiveKid string       `mapstructure:"active_kid"`
+	Keys      []SigningKey `mapstructure:"keys"`
+}
+
+// SigningKey JWT 签名密钥，密钥以 PEM 文件的形式提供
+type SigningKey struct {
+	Kid        string `mapstructure:"kid"`
+	Alg        string `mapstructure:"alg"`         // RS256 或 EdDSA
+	PrivateKey string `mapstructure:"private_key"` // 私钥文件路径，已下线只用于校验的密钥可以不填
+	PublicKey  string `mapstructure:"public_key"`  // 公钥文件路径，填写了私钥时忽略
+}
+
+// PasswordPolicy 密码强度策略，长度为零值时使用默认值 8-64
+type PasswordPolicy struct {
+	MinLength     int  `mapstructure:"min_length"`
+	MaxLength     int  `mapstructure:"max_length"`
+	RequireUpper  bool `mapstructure:"require_upper"`  // 需要包含大写字母
+	RequireLower  bool `mapstructure:"require_lower"`  // 需要包含小写字母
+	RequireDigit  bool `mapstructure:"require_digit"`  // 需要包含数字
+	RequireSymbol bool `mapstructure:"require_symbol"` // 需要包含特殊符号
+}
+
+// Argon2 密码哈希的代价参数，零值使用默认值
+type Argon2 struct {
+	Time    uint32 `mapstructure:"time"`     // 迭代次数
+	Memory  uint32 `mapstructure:"memory"`   // 内存开销，单位 KiB
+	Threads uint8  `mapstructure:"threads"`  // 并行度
+	SaltLen uint32 `mapstructure:"salt_len"` // 盐长度，单位字节
+	KeyLen  uint32 `mapstructure:"key_len"`  // 哈希长度，单位字节
 }
 
 type Email struct {
@@ -71,6 +148,81 @@ type Email struct {
 	AuthCode     string `mapstructure:"auth_code"`
 	SendNickname string `mapstructure:"send_nickname"`
 	Subject      string `mapstructure:"subject"`
-	SSL          bool   `mapstructure:"ssl"`
-	TLS          bool   `mapstructure:"tls"`
+	SSL          bool   `mapstructure:"ssl"`   // 隐式 TLS 连接（465 端口）
+	TLS          bool   `mapstructure:"tls"`   // 明文连接后必须通过 STARTTLS 升级（587 端口）
+	Plain        bool   `mapstructure:"plain"` // 始终使用明文连接，三者都不开启时在服务器支持时通过 STARTTLS 升级
+	// 邮件驱动：smtp（默认）、file（写入 Maildir 目录）、memory（只记录在内存中）
+	Driver  string `mapstructure:"driver"`
+	Maildir string `mapstructure:"maildir"` // file 驱动写入的目录
+	// 邮件模板目录，其中的模板覆盖内置模板，为空时只使用内置模板
+	TemplateDir string `mapstructure:"template_dir"`
+	// 修改邮箱后发给旧邮箱的撤销链接，撤销令牌以 token 查询参数追加在其后
+	// 为空时邮件中只给出撤销令牌
+	RevertURL string `mapstructure:"revert_url"`
+}
+
+// Storage 对象存储配置，driver 为 local（默认）或 s3
+type Storage struct {
+	Driver string       `mapstructure:"driver"`
+	Local  LocalStorage `mapstructure:"local"`
+	S3     S3Storage    `mapstructure:"s3"`
+}
+
+// LocalStorage 本地磁盘存储，文件通过 URLPrefix 下的静态路由访问
+type LocalStorage struct {
+	Dir       string `mapstructure:"dir"`
+	URLPrefix string `mapstructure:"url_prefix"`
+}
+
+// S3Storage S3 兼容的对象存储
+type S3Storage struct {
+	Endpoint  string `mapstructure:"endpoint"` // 例如 s3.amazonaws.com、127.0.0.1:9000
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	UseSSL    bool   `mapstructure:"use_ssl"`
+	PublicURL string `mapstructure:"public_url"` // 对外访问的地址前缀，例如 CDN 域名，为空时使用 endpoint/bucket
+}
+
+// Telemetry OpenTelemetry 链路追踪配置，不开启时不产生任何 span
+type Telemetry struct {
+	Enable      bool   `mapstructure:"enable"`
+	ServiceName string `mapstructure:"service_name"` // 为空时使用 nurture
+	// 导出方式：otlp（默认）、stdout（打印到控制台）、file（以 JSON 追加写入 File）
+	Exporter string `mapstructure:"exporter"`
+	// otlp 导出的协议 grpc（默认）或 http，Endpoint 为 host:port，例如 127.0.0.1:4317
+	Protocol string            `mapstructure:"protocol"`
+	Endpoint string            `mapstructure:"endpoint"`
+	Insecure bool              `mapstructure:"insecure"` // 不使用 TLS 连接
+	Headers  map[string]string `mapstructure:"headers"`  // 例如认证需要的请求头
+	File     string            `mapstructure:"file"`
+	// 采样比例，0 到 1 之间，零值为全部采样；调用方的 traceparent 已经决定是否采样时以调用方为准
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// Metrics Prometheus 指标的暴露方式
+type Metrics struct {
+	Enable bool   `mapstructure:"enable"`
+	Path   string `mapstructure:"path"` // 为空时使用 /metrics
+	// 单独监听的端口，为 0 或与 app.port 相同时挂在业务端口上，业务端口上的指标没有认证，只允许在 dev 环境使用
+	Port int    `mapstructure:"port"`
+	Host string `mapstructure:"host"` // 单独监听的地址，为空时只监听 127.0.0.1
+}
+
+// Addr 返回单独监听指标的地址
+func (m *Metrics) Addr() string {
+	host := m.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return fmt.Sprintf("%s:%d", host, m.Port)
+}
+
+// MetricsPath 返回指标的路径
+func (m *Metrics) MetricsPath() string {
+	if m.Path == "" {
+		return "/metrics"
+	}
+	return m.Path
 }
@@ -1,14 +1,62 @@
 package constant
 
+import "time"
+
 // 所有常量文件读取位置
 const (
-	TOKEN_USER_ID      = "UserID"
-	TOKEN_ROLE         = "Role"
-	LOGIN_WITH_ACCOUNT = "account"
-	LOGIN_WITH_EMAIL   = "email"
-	DEFAULT_NODE_ID    = 1
-	FILE_MAX_SIZE      = 1024 * 1024 * 10
-	LOGIN_CODE_KEY     = "login_code:%s"
-	RESET_PWD_CODE_KEY = "reset_pwd_code:%s"
-	REGISTER_CODE_KEY  = "register_code:%s"
+	TOKEN_USER_ID           = "UserID"
+	TOKEN_ROLE              = "Role"
+	TOKEN_SESSION_ID        = "SessionID"
+	LOGIN_WITH_ACCOUNT      = "account"
+	LOGIN_WITH_EMAIL        = "email"
+	DEFAULT_NODE_ID         = 1
+	FILE_MAX_SIZE           = 1024 * 1024 * 10
+	AVATAR_SIZE             = 256 // 头像的标准尺寸，单位像素
+	AVATAR_SMALL_SIZE       = 64  // 列表等场景使用的小头像尺寸
+	AVATAR_KEY              = "avatar/%s/%s_%d.jpg"
+	AVATAR_DIR              = "avatar/%s" // 用户所有头像所在的目录，注销账号时整体删除
+	LOGIN_CODE_KEY          = "login_code:%s"
+	RESET_PWD_CODE_KEY      = "reset_pwd_code:%s"
+	REGISTER_CODE_KEY       = "register_code:%s"
+	CHANGE_EMAIL_CODE_KEY   = "change_email_code:%s:%s" // 用户ID和新邮箱，验证码只能用于发起时的账号
+	DELETE_ACCOUNT_CODE_KEY = "delete_account_code:%s"
+	ACCOUNT_DELETE_GRACE    = 30 * 24 * time.Hour // 注销后的宽限期，期间重新登录即取消注销
+	ACCOUNT_PURGE_WAIT      = time.Hour           // 清理已过宽限期账号的间隔
+	ACCOUNT_PURGE_BATCH     = 100                 // 每个事务最多删除的账号数
+	EMAIL_REVERT_TTL        = 7 * 24 * time.Hour  // 修改邮箱后旧邮箱撤销链接的有效期
+	CODE_TTL                = 10 * time.Minute    // 验证码有效期
+	CODE_RESEND_WAIT        = 60 * time.Second    // 同一邮箱再次获取验证码的冷却时间
+	CODE_IP_LIMIT           = 20                  // 同一 IP 每个窗口内最多获取验证码的次数
+	CODE_IP_WINDOW          = time.Hour
+	CODE_EMAIL_KEY          = "rate_limit:code:%s:email:%s" // 验证码用途和邮箱，不同用途的冷却时间互不影响
+	CODE_IP_KEY             = "rate_limit:code:ip:%s"
+	CODE_USER_KEY           = "rate_limit:code:%s:user:%s"
+	CODE_MAX_ATTEMPTS       = 5              // 验证码允许输错的次数，超过后验证码作废
+	LOGIN_MAX_FAILS         = 5              // 连续输错密码多少次后锁定账号
+	LOGIN_LOCK_BASE         = time.Minute    // 首次锁定时长，之后每多错一次翻倍
+	LOGIN_LOCK_MAX          = time.Hour      // 单次锁定的最长时间
+	LOGIN_FAIL_WINDOW       = 24 * time.Hour // 失败次数在最后一次失败后保留的时间
+	LOGIN_GUARD_KEY         = "login_guard:%s"
+	REAUTH_GUARD_KEY        = "reauth_guard:%s"
+	ADMIN_PAGE_SIZE         = 20               // 管理后台列表默认的每页条数
+	SESSION_TOUCH_WAIT      = time.Minute      // 会话最近访问时间的最小更新间隔，避免每个请求都写库
+	OUTBOX_WORKERS          = 4                // 并发投递邮件的 worker 数
+	OUTBOX_POLL_WAIT        = time.Second      // 发件箱为空时的轮询间隔
+	OUTBOX_LEASE            = time.Minute      // 单次投递的租约，超过后视为 worker 已失效并重新投递
+	OUTBOX_SEND_TIMEOUT     = 30 * time.Second // 单次投递的超时时间，需要小于租约
+	OUTBOX_MAX_ATTEMPTS     = 8                // 最多投递次数，超过后进入死信
+	OUTBOX_RETRY_BASE       = 10 * time.Second // 首次重试的等待时间，之后每次翻倍
+	OUTBOX_RETRY_MAX        = 30 * time.Minute // 重试等待时间的上限
+	SERVER_READ_TIMEOUT     = 30 * time.Second // 未配置 app.read_timeout 时读取整个请求的超时时间，需要容纳头像上传
+	SERVER_WRITE_TIMEOUT    = 30 * time.Second // 未配置 app.write_timeout 时写响应的超时时间，需要容纳数据导出
+	SERVER_IDLE_TIMEOUT     = 2 * time.Minute  // 未配置 app.idle_timeout 时 keep-alive 连接的空闲时间
+	SERVER_SHUTDOWN_TIMEOUT = 20 * time.Second // 未配置 app.shutdown_timeout 时退出的最长等待时间
+)
+
+// 发件箱中邮件的状态
+const (
+	EMAIL_PENDING = 0 // 待投递
+	EMAIL_SENDING = 1 // 投递中
+	EMAIL_SENT    = 2 // 已投递
+	EMAIL_DEAD    = 3 // 多次投递失败，进入死信
 )
@@ -0,0 +1,65 @@
+package dto
+
+type (
+	// DeleteAccountReq 注销账号需要重新验证身份，密码和邮箱验证码二选一
+	DeleteAccountReq struct {
+		Password string `json:"password" binding:"required_without=Code,omitempty,max=128" log:"redact"`
+		Code     string `json:"code" binding:"required_without=Password,omitempty,len=6,numeric" log:"redact"`
+	}
+	DeleteAccountResp struct {
+		PurgeAt int64  `json:"purge_at"` // 数据被彻底删除的时间，在此之前重新登录即取消注销
+		Message string `json:"message"`
+	}
+)
+
+type (
+	// ExportResp 用户数据导出，不包含密码哈希和令牌摘要等凭据
+	ExportResp struct {
+		ExportedAt    int64                `json:"exported_at"`
+		User          ExportUser           `json:"user"`
+		Sessions      []ExportSession      `json:"sessions"`
+		RefreshTokens []ExportRefreshToken `json:"refresh_tokens"`
+		EmailChanges  []ExportEmailChange  `json:"email_changes"`
+		Emails        []ExportEmail        `json:"emails"`
+	}
+	ExportUser struct {
+		ProfileResp
+		TokenVersion int32 `json:"token_version"`
+		DeletedAt    int64 `json:"deleted_at"`
+	}
+	ExportSession struct {
+		SessionID  string `json:"session_id"`
+		UserAgent  string `json:"user_agent"`
+		IP         string `json:"ip"`
+		Ctime      int64  `json:"ctime"`
+		LastSeen   int64  `json:"last_seen"`
+		ExpireTime int64  `json:"expire_time"`
+		Revoked    bool   `json:"revoked"`
+	}
+	ExportRefreshToken struct {
+		FamilyID   string `json:"family_id"` // 与会话ID一致
+		Ctime      int64  `json:"ctime"`
+		ExpireTime int64  `json:"expire_time"`
+		Used       bool   `json:"used"`
+		Revoked    bool   `json:"revoked"`
+	}
+	ExportEmailChange struct {
+		OldEmail   string `json:"old_email"`
+		NewEmail   string `json:"new_email"`
+		Ctime      int64  `json:"ctime"`
+		ExpireTime int64  `json:"expire_time"`
+		Reverted   bool   `json:"reverted"`
+	}
+	// ExportEmail 发给用户的邮件，正文在投递成功后已经删除
+	ExportEmail struct {
+		MessageID string `json:"message_id"`
+		Recipient string `json:"recipient"`
+		Template  string `json:"template"`
+		Locale    string `json:"locale"`
+		Subject   string `json:"subject"`
+		Status    int16  `json:"status"`
+		Attempts  int32  `json:"attempts"`
+		Ctime     int64  `json:"ctime"`
+		Utime     int64  `json:"utime"`
+	}
+)
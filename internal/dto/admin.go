@@ -0,0 +1,40 @@
+package dto
+
+type (
+	// ListUsersReq 用户列表的查询参数，未传的筛选条件不生效
+	ListUsersReq struct {
+		Page     int32  `form:"page" binding:"omitempty,min=1"`
+		PageSize int32  `form:"page_size" binding:"omitempty,min=1,max=100"`
+		Keyword  string `form:"keyword" binding:"max=64"` // 匹配账号、邮箱或用户名
+		Role     *int16 `form:"role" binding:"omitempty,oneof=1 2 3"`
+		Disabled *bool  `form:"disabled"`
+		Deleted  *bool  `form:"deleted"` // 是否处于注销宽限期
+	}
+	ListUsersResp struct {
+		Users    []AdminUserItem `json:"users"`
+		Total    int64           `json:"total"`
+		Page     int32           `json:"page"`
+		PageSize int32           `json:"page_size"`
+	}
+	// AdminUserItem 管理员看到的用户信息，在用户资料的基础上增加账号状态
+	AdminUserItem struct {
+		ProfileResp
+		Disabled          bool  `json:"disabled"`
+		MustResetPassword bool  `json:"must_reset_password"`
+		DeletedAt         int64 `json:"deleted_at"`
+	}
+)
+
+type (
+	AdminUserReq struct {
+		UserID string `uri:"user_id" binding:"required,uuid"`
+	}
+	UpdateRoleReq struct {
+		UserID string `uri:"user_id" json:"-" binding:"required,uuid"`
+		Role   int16  `json:"role" binding:"required,oneof=1 2 3"`
+	}
+	AdminUserActionResp struct {
+		User    AdminUserItem `json:"user"`
+		Message string        `json:"message"`
+	}
+)
@@ -0,0 +1,62 @@
+package dto
+
+type (
+	ProfileResp struct {
+		UserID   string `json:"user_id"`
+		Account  string `json:"account"`
+		Username string `json:"username"`
+		Email    string `json:"email"`
+		Avatar   string `json:"avatar"`
+		Role     int16  `json:"role"`
+		Locale   string `json:"locale"`
+		Ctime    int64  `json:"ctime"`
+		Utime    int64  `json:"utime"`
+	}
+)
+
+type (
+	// UpdateProfileReq 只修改请求中出现的字段
+	UpdateProfileReq struct {
+		Username *string `json:"username" binding:"omitempty,min=1,max=20"`
+		Avatar   *string `json:"avatar" binding:"omitempty,url,max=255"` // 只能是自己上传的头像地址，空字符串表示清空头像
+	}
+)
+
+type (
+	UploadAvatarResp struct {
+		Avatar  string            `json:"avatar"`  // 标准尺寸头像的地址，同时写入用户资料
+		Avatars map[string]string `json:"avatars"` // 各尺寸头像的地址，key 为边长
+	}
+)
+
+type (
+	ChangePasswordReq struct {
+		OldPassword string `json:"old_password" binding:"required,max=128" log:"redact"`
+		NewPassword string `json:"new_password" binding:"required,password" log:"redact"`
+	}
+	// ChangePasswordResp 修改密码后旧的访问令牌失效，返回当前会话的新访问令牌
+	ChangePasswordResp struct {
+		Token    string `json:"token" log:"redact"`
+		ExpireIn int64  `json:"expire_in"` // 访问令牌有效期，单位秒
+		Message  string `json:"message"`
+	}
+)
+
+type (
+	ChangeEmailReq struct {
+		Email string `json:"email" binding:"required,email,max=254"`
+		Code  string `json:"code" binding:"required,len=6,numeric" log:"redact"`
+	}
+	ChangeEmailResp struct {
+		Email   string `json:"email"`
+		Message string `json:"message"`
+	}
+	// RevertEmailReq 撤销令牌来自发给旧邮箱的通知邮件
+	RevertEmailReq struct {
+		Token string `json:"token" binding:"required,max=128" log:"redact"`
+	}
+	RevertEmailResp struct {
+		Email   string `json:"email"` // 恢复后的邮箱
+		Message string `json:"message"`
+	}
+)
@@ -0,0 +1,37 @@
+package dto
+
+// ClientInfo 发起请求的客户端信息，由 handler 从请求中填充，不从请求体绑定
+type ClientInfo struct {
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
+
+type (
+	SessionItem struct {
+		SessionID string `json:"session_id"`
+		UserAgent string `json:"user_agent"`
+		IP        string `json:"ip"`
+		Ctime     int64  `json:"ctime"`
+		LastSeen  int64  `json:"last_seen"`
+		Current   bool   `json:"current"` // 是否为发起本次请求的会话
+	}
+	ListSessionResp struct {
+		Sessions []SessionItem `json:"sessions"`
+	}
+)
+
+type (
+	RevokeSessionReq struct {
+		SessionID string `uri:"session_id" binding:"required,uuid"`
+	}
+	RevokeSessionResp struct {
+		Message string `json:"message"`
+	}
+)
+
+type (
+	RevokeOtherSessionsResp struct {
+		Count   int64  `json:"count"` // 被注销的会话数
+		Message string `json:"message"`
+	}
+)
@@ -0,0 +1,21 @@
+package dto
+
+type (
+	RefreshTokenReq struct {
+		RefreshToken string `json:"refresh_token" binding:"required" log:"redact"`
+	}
+	RefreshTokenResp struct {
+		Token        string `json:"token" log:"redact"`
+		RefreshToken string `json:"refresh_token" log:"redact"`
+		ExpireIn     int64  `json:"expire_in"` // 访问令牌有效期，单位秒
+	}
+)
+
+type (
+	LogoutReq struct {
+		RefreshToken string `json:"refresh_token" binding:"required" log:"redact"`
+	}
+	LogoutResp struct {
+		Message string `json:"message"`
+	}
+)
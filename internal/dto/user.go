@@ -2,35 +2,42 @@ package dto
 
 type (
 	LoginReq struct {
-		Account   string `json:"account"`
-		Password  string `json:"password"`
-		Email     string `json:"email"`
-		Code      string `json:"code"`
-		LoginType string `json:"login_type"`
+		Account   string     `json:"account" binding:"required_if=LoginType account,max=20"`
+		Password  string     `json:"password" binding:"required_if=LoginType account,max=128" log:"redact"`
+		Email     string     `json:"email" binding:"required_if=LoginType email,omitempty,email,max=254"`
+		Code      string     `json:"code" binding:"required_if=LoginType email,omitempty,len=6,numeric" log:"redact"`
+		LoginType string     `json:"login_type" binding:"required,oneof=account email"`
+		Client    ClientInfo `json:"-"`
 	}
 	LoginResp struct {
-		Token    string `json:"token"`
-		Username string `json:"username"`
-		Avatar   string `json:"avatar"`
+		Token        string `json:"token" log:"redact"`
+		RefreshToken string `json:"refresh_token" log:"redact"`
+		ExpireIn     int64  `json:"expire_in"` // 访问令牌有效期，单位秒
+		Username     string `json:"username"`
+		Avatar       string `json:"avatar"`
+		Locale       string `json:"locale"` // 偏好语言
 	}
 )
 
 type (
 	GetCodeReq struct {
-		Email string `json:"email"`
+		Email string `json:"email" binding:"required,email,max=254"`
 	}
 	GetCodeResp struct {
-		Code string `json:"code"`
+		MessageID  string `json:"message_id"`                  // 验证码邮件的ID，可用于查询投递状态
+		ExpireIn   int64  `json:"expire_in"`                   // 验证码有效期，单位秒
+		RetryAfter int64  `json:"retry_after"`                 // 再次获取验证码前需要等待的时间，单位秒
+		Code       string `json:"code,omitempty" log:"redact"` // 仅在 dev 回显模式下返回
 	}
 )
 
 type (
 	RegisterReq struct {
-		Account  string `json:"account"`
-		Password string `json:"password"`
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Code     string `json:"code"`
+		Account  string `json:"account" binding:"required,account"`
+		Password string `json:"password" binding:"required,password" log:"redact"`
+		Username string `json:"username" binding:"required,max=20"`
+		Email    string `json:"email" binding:"required,email,max=254"`
+		Code     string `json:"code" binding:"required,len=6,numeric" log:"redact"`
 	}
 	RegisterResp struct {
 		Message string `json:"message"`
@@ -39,11 +46,34 @@ type (
 
 type (
 	ResetPasswordReq struct {
-		Email       string `json:"email"`
-		Code        string `json:"code"`
-		NewPassword string `json:"new_password"`
+		Email       string `json:"email" binding:"required,email,max=254"`
+		Code        string `json:"code" binding:"required,len=6,numeric" log:"redact"`
+		NewPassword string `json:"new_password" binding:"required,password" log:"redact"`
 	}
 	ResetPasswordResp struct {
 		Message string `json:"message"`
 	}
 )
+
+type (
+	UpdateLocaleReq struct {
+		Locale string `json:"locale" binding:"required,locale"`
+	}
+	UpdateLocaleResp struct {
+		Locale  string `json:"locale"`
+		Message string `json:"message"`
+	}
+)
+
+type (
+	EmailStatusReq struct {
+		MessageID string `uri:"message_id" binding:"required,uuid"`
+	}
+	EmailStatusResp struct {
+		MessageID string `json:"message_id"`
+		Status    string `json:"status"`   // pending 待投递，sending 投递中，sent 已投递，dead 投递失败
+		Attempts  int32  `json:"attempts"` // 已尝试投递的次数
+		Ctime     int64  `json:"ctime"`
+		Utime     int64  `json:"utime"`
+	}
+)
@@ -1,9 +1,20 @@
 package global
 
 import (
+	"context"
+	"io"
+	"nurture/internal/config"
+	"nurture/internal/pkg/codex"
+	"nurture/internal/pkg/jwtx"
+	"nurture/internal/pkg/lifecyclex"
+	"nurture/internal/pkg/limitx"
+	"nurture/internal/pkg/lockx"
+	"nurture/internal/pkg/metricx"
+	"nurture/internal/pkg/otelx"
 	"nurture/internal/pkg/pgsqlx"
 	"nurture/internal/pkg/redisx"
-	"nurture/internal/pkg/syncx"
+	"nurture/internal/pkg/storagex"
+	"nurture/internal/pkg/tplx"
 	"nurture/internal/pkg/zapx"
 
 	"github.com/go-redis/redis/v8"
@@ -12,15 +23,41 @@ import (
 )
 
 var (
-	Log       *zap.SugaredLogger
-	DB        *pgxpool.Pool
-	RDB       redis.Cmdable
-	CodeStore *syncx.Map[string, string]
+	Log           *zap.SugaredLogger
+	DB            *pgxpool.Pool
+	RDB           redis.Cmdable
+	CodeStore     codex.CodeStore
+	Limiter       limitx.Limiter
+	Locker        lockx.Locker
+	MailTemplates *tplx.Registry
+	Storage       storagex.Storage
+	// Lifecycle 退出时按初始化的逆序停止的组件，后台任务和 HTTP 服务在 Init 之后注册，因此最先停止
+	Lifecycle *lifecyclex.Manager
 )
 
 func Init() {
 	Log = zapx.InitZap()
+	Lifecycle = lifecyclex.New(Log)
+	Lifecycle.Add("日志", func(context.Context) error {
+		// 输出到控制台时 Sync 会返回 invalid argument，忽略
+		_ = Log.Sync()
+		return nil
+	})
+	// 链路追踪需要在数据库和 Redis 之前初始化，退出时在它们之后停止，保证最后的 span 能导出
+	Lifecycle.Add("链路追踪", lifecyclex.StopFunc(otelx.InitTracer(config.Conf.Telemetry, config.Conf.App.Env)))
 	DB = pgsqlx.InitPgsql()
+	// Close 会等待借出的连接归还
+	Lifecycle.Add("数据库", lifecyclex.Wait(DB.Close))
 	RDB = redisx.InitRedis()
-	CodeStore = new(syncx.Map[string, string])
+	if closer, ok := RDB.(io.Closer); ok {
+		Lifecycle.Add("Redis", func(context.Context) error { return closer.Close() })
+	}
+	metricx.RegisterPgxPool(DB)
+	metricx.RegisterRedisPool(RDB)
+	jwtx.InitKeys()
+	CodeStore = codex.InitCodeStore(RDB)
+	Limiter = limitx.InitLimiter(RDB)
+	Locker = lockx.InitLocker(RDB)
+	MailTemplates = tplx.InitTemplates(config.Conf.Email.TemplateDir)
+	Storage = storagex.InitStorage(config.Conf.Storage)
 }
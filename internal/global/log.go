@@ -0,0 +1,29 @@
+package global
+
+import (
+	"context"
+	"nurture/internal/pkg/tracex"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Logger 返回带有 ctx 中请求ID的日志器，logic 和 repo 层有 ctx 时应使用它代替 Log
+// 请求ID与链路追踪的 trace-id 不同时（调用方传入了 X-Request-ID）同时记录 trace_id
+func Logger(ctx context.Context) *zap.SugaredLogger {
+	if ctx == nil {
+		return Log
+	}
+	var fields []any
+	requestID := tracex.RequestID(ctx)
+	if requestID != "" {
+		fields = append(fields, "request_id", requestID)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() && sc.TraceID().String() != requestID {
+		fields = append(fields, "trace_id", sc.TraceID().String())
+	}
+	if len(fields) == 0 {
+		return Log
+	}
+	return Log.With(fields...)
+}
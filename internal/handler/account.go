@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"fmt"
+	"nurture/internal/dto"
+	"nurture/internal/logic"
+	"nurture/internal/middleware"
+	"nurture/internal/pkg/jwtx"
+	"nurture/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountLogic *logic.AccountLogic
+}
+
+func NewAccountHandler() *AccountHandler {
+	return &AccountHandler{
+		accountLogic: logic.NewAccountLogic(),
+	}
+}
+
+func (ah *AccountHandler) GetDeleteAccountCode(c *gin.Context) {
+	resp, err := ah.accountLogic.GetDeleteAccountCode(c.Request.Context(), jwtx.GetUserID(c))
+	response.Response(c, resp, err)
+}
+
+func (ah *AccountHandler) DeleteAccount(c *gin.Context) {
+	cr := middleware.GetBind[dto.DeleteAccountReq](c)
+	resp, err := ah.accountLogic.DeleteAccount(c.Request.Context(), jwtx.GetUserID(c), cr)
+	response.Response(c, resp, err)
+}
+
+// Export 导出的数据以附件形式下载
+func (ah *AccountHandler) Export(c *gin.Context) {
+	userID := jwtx.GetUserID(c)
+	resp, err := ah.accountLogic.Export(c.Request.Context(), userID)
+	if err == nil {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%s.json"`, userID))
+		c.Header("Cache-Control", "no-store")
+	}
+	response.Response(c, resp, err)
+}
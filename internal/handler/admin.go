@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"nurture/internal/dto"
+	"nurture/internal/logic"
+	"nurture/internal/middleware"
+	"nurture/internal/pkg/jwtx"
+	"nurture/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	adminLogic *logic.AdminLogic
+}
+
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{
+		adminLogic: logic.NewAdminLogic(),
+	}
+}
+
+func (ah *AdminHandler) ListUsers(c *gin.Context) {
+	cr := middleware.GetBind[dto.ListUsersReq](c)
+	resp, err := ah.adminLogic.ListUsers(c.Request.Context(), cr)
+	response.Response(c, resp, err)
+}
+
+func (ah *AdminHandler) GetUser(c *gin.Context) {
+	cr := middleware.GetBind[dto.AdminUserReq](c)
+	resp, err := ah.adminLogic.GetUser(c.Request.Context(), cr)
+	response.Response(c, resp, err)
+}
+
+func (ah *AdminHandler) UpdateRole(c *gin.Context) {
+	cr := middleware.GetBind[dto.UpdateRoleReq](c)
+	resp, err := ah.adminLogic.UpdateRole(c.Request.Context(), jwtx.GetUserID(c), cr)
+	response.Response(c, resp, err)
+}
+
+func (ah *AdminHandler) DisableUser(c *gin.Context) {
+	cr := middleware.GetBind[dto.AdminUserReq](c)
+	resp, err := ah.adminLogic.SetDisabled(c.Request.Context(), jwtx.GetUserID(c), cr, true)
+	response.Response(c, resp, err)
+}
+
+func (ah *AdminHandler) EnableUser(c *gin.Context) {
+	cr := middleware.GetBind[dto.AdminUserReq](c)
+	resp, err := ah.adminLogic.SetDisabled(c.Request.Context(), jwtx.GetUserID(c), cr, false)
+	response.Response(c, resp, err)
+}
+
+func (ah *AdminHandler) RequirePasswordReset(c *gin.Context) {
+	cr := middleware.GetBind[dto.AdminUserReq](c)
+	resp, err := ah.adminLogic.RequirePasswordReset(c.Request.Context(), jwtx.GetUserID(c), cr)
+	response.Response(c, resp, err)
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"net/http"
+	"nurture/internal/pkg/jwtx"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommonHandler struct{}
+
+func NewCommonHandler() *CommonHandler {
+	return &CommonHandler{}
+}
+
+// JWKS 公开 JWT 的校验公钥，按标准格式直接返回，不包裹统一的响应体
+func (ch *CommonHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwtx.GetJWKS())
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"nurture/internal/dto"
+	"nurture/internal/logic"
+	"nurture/internal/middleware"
+	"nurture/internal/pkg/jwtx"
+	"nurture/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailHandler struct {
+	emailLogic *logic.EmailLogic
+}
+
+func NewEmailHandler() *EmailHandler {
+	return &EmailHandler{
+		emailLogic: logic.NewEmailLogic(),
+	}
+}
+
+func (eh *EmailHandler) GetEmailStatus(c *gin.Context) {
+	cr := middleware.GetBind[dto.EmailStatusReq](c)
+	resp, err := eh.emailLogic.GetEmailStatus(c.Request.Context(), jwtx.GetUserID(c), cr)
+	response.Response(c, resp, err)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"nurture/internal/dto"
+	"nurture/internal/logic"
+	"nurture/internal/middleware"
+	"nurture/internal/pkg/jwtx"
+	"nurture/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	sessionLogic *logic.SessionLogic
+}
+
+func NewSessionHandler() *SessionHandler {
+	return &SessionHandler{
+		sessionLogic: logic.NewSessionLogic(),
+	}
+}
+
+func (sh *SessionHandler) ListSessions(c *gin.Context) {
+	resp, err := sh.sessionLogic.ListSessions(c.Request.Context(), jwtx.GetUserID(c), jwtx.GetSessionID(c))
+	response.Response(c, resp, err)
+}
+
+func (sh *SessionHandler) RevokeSession(c *gin.Context) {
+	cr := middleware.GetBind[dto.RevokeSessionReq](c)
+	resp, err := sh.sessionLogic.RevokeSession(c.Request.Context(), jwtx.GetUserID(c), cr)
+	response.Response(c, resp, err)
+}
+
+func (sh *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	resp, err := sh.sessionLogic.RevokeOtherSessions(c.Request.Context(), jwtx.GetUserID(c), jwtx.GetSessionID(c))
+	response.Response(c, resp, err)
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"nurture/internal/dto"
+	"nurture/internal/logic"
+	"nurture/internal/middleware"
+	"nurture/internal/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TokenHandler struct {
+	tokenLogic *logic.TokenLogic
+}
+
+func NewTokenHandler() *TokenHandler {
+	return &TokenHandler{
+		tokenLogic: logic.NewTokenLogic(),
+	}
+}
+
+func (th *TokenHandler) Refresh(c *gin.Context) {
+	cr := middleware.GetBind[dto.RefreshTokenReq](c)
+	resp, err := th.tokenLogic.Refresh(c.Request.Context(), cr)
+	response.Response(c, resp, err)
+}
+
+func (th *TokenHandler) Logout(c *gin.Context) {
+	cr := middleware.GetBind[dto.LogoutReq](c)
+	resp, err := th.tokenLogic.Logout(c.Request.Context(), cr)
+	response.Response(c, resp, err)
+}
@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+	"nurture/internal/constant"
 	"nurture/internal/dto"
-	"nurture/internal/global"
 	"nurture/internal/logic"
 	"nurture/internal/middleware"
+	"nurture/internal/pkg/jwtx"
 	"nurture/internal/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -22,42 +25,106 @@ func NewUserHandler() *UserHandler {
 
 func (uh *UserHandler) Login(c *gin.Context) {
 	cr := middleware.GetBind[dto.LoginReq](c)
-	global.Log.Info(cr)
+	cr.Client = dto.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
 	resp, err := uh.userLogic.Login(c.Request.Context(), cr)
 	response.Response(c, resp, err)
 }
 
 func (uh *UserHandler) Register(c *gin.Context) {
 	cr := middleware.GetBind[dto.RegisterReq](c)
-	global.Log.Info(cr)
 	resp, err := uh.userLogic.Register(c.Request.Context(), cr)
 	response.Response(c, resp, err)
 }
 
 func (uh *UserHandler) ResetPassword(c *gin.Context) {
 	cr := middleware.GetBind[dto.ResetPasswordReq](c)
-	global.Log.Info(cr)
 	resp, err := uh.userLogic.ResetPassword(c.Request.Context(), cr)
 	response.Response(c, resp, err)
 }
 
 func (uh *UserHandler) GetLoginCode(c *gin.Context) {
 	cr := middleware.GetBind[dto.GetCodeReq](c)
-	global.Log.Info(cr)
 	resp, err := uh.userLogic.GetLoginCode(c.Request.Context(), cr)
 	response.Response(c, resp, err)
 }
 
 func (uh *UserHandler) GetRegisterCode(c *gin.Context) {
 	cr := middleware.GetBind[dto.GetCodeReq](c)
-	global.Log.Info(cr)
 	resp, err := uh.userLogic.GetRegisterCode(c.Request.Context(), cr)
 	response.Response(c, resp, err)
 }
 
 func (uh *UserHandler) GetResetCode(c *gin.Context) {
 	cr := middleware.GetBind[dto.GetCodeReq](c)
-	global.Log.Info(cr)
 	resp, err := uh.userLogic.GetResetCode(c.Request.Context(), cr)
 	response.Response(c, resp, err)
 }
+
+func (uh *UserHandler) UpdateLocale(c *gin.Context) {
+	cr := middleware.GetBind[dto.UpdateLocaleReq](c)
+	resp, err := uh.userLogic.UpdateLocale(c.Request.Context(), jwtx.GetUserID(c), cr)
+	response.Response(c, resp, err)
+}
+
+func (uh *UserHandler) GetProfile(c *gin.Context) {
+	resp, err := uh.userLogic.GetProfile(c.Request.Context(), jwtx.GetUserID(c))
+	response.Response(c, resp, err)
+}
+
+func (uh *UserHandler) UpdateProfile(c *gin.Context) {
+	cr := middleware.GetBind[dto.UpdateProfileReq](c)
+	resp, err := uh.userLogic.UpdateProfile(c.Request.Context(), jwtx.GetUserID(c), cr)
+	response.Response(c, resp, err)
+}
+
+func (uh *UserHandler) ChangePassword(c *gin.Context) {
+	cr := middleware.GetBind[dto.ChangePasswordReq](c)
+	resp, err := uh.userLogic.ChangePassword(c.Request.Context(), jwtx.GetUserID(c), jwtx.GetSessionID(c), cr)
+	response.Response(c, resp, err)
+}
+
+func (uh *UserHandler) GetChangeEmailCode(c *gin.Context) {
+	cr := middleware.GetBind[dto.GetCodeReq](c)
+	resp, err := uh.userLogic.GetChangeEmailCode(c.Request.Context(), jwtx.GetUserID(c), cr)
+	response.Response(c, resp, err)
+}
+
+func (uh *UserHandler) ChangeEmail(c *gin.Context) {
+	cr := middleware.GetBind[dto.ChangeEmailReq](c)
+	resp, err := uh.userLogic.ChangeEmail(c.Request.Context(), jwtx.GetUserID(c), cr)
+	response.Response(c, resp, err)
+}
+
+func (uh *UserHandler) RevertEmail(c *gin.Context) {
+	cr := middleware.GetBind[dto.RevertEmailReq](c)
+	resp, err := uh.userLogic.RevertEmail(c.Request.Context(), cr)
+	response.Response(c, resp, err)
+}
+
+// UploadAvatar 以 multipart/form-data 上传头像，文件字段名为 avatar
+func (uh *UserHandler) UploadAvatar(c *gin.Context) {
+	// 额外预留 multipart 边界等开销，文件本身的大小由 logic 层校验
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, constant.FILE_MAX_SIZE+1024*1024)
+	fh, err := c.FormFile("avatar")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			response.Response(c, nil, logic.ErrFileOverSize)
+			return
+		}
+		response.Response(c, nil, logic.ErrFileRead)
+		return
+	}
+	if fh.Size > constant.FILE_MAX_SIZE {
+		response.Response(c, nil, logic.ErrFileOverSize)
+		return
+	}
+	file, err := fh.Open()
+	if err != nil {
+		response.Response(c, nil, logic.ErrFileRead)
+		return
+	}
+	defer file.Close()
+	resp, err := uh.userLogic.UploadAvatar(c.Request.Context(), jwtx.GetUserID(c), file)
+	response.Response(c, resp, err)
+}
@@ -0,0 +1,219 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nurture/internal/constant"
+	"nurture/internal/dto"
+	"nurture/internal/global"
+	"nurture/internal/pkg/emailx"
+	"nurture/internal/pkg/i18nx"
+	"nurture/internal/pkg/storagex"
+	"nurture/internal/repo"
+	"nurture/internal/repo/user"
+	"sync"
+	"time"
+)
+
+type IAccountLogic interface {
+	GetDeleteAccountCode(ctx context.Context, userID string) (dto.GetCodeResp, error)
+	DeleteAccount(ctx context.Context, userID string, req dto.DeleteAccountReq) (dto.DeleteAccountResp, error)
+	Export(ctx context.Context, userID string) (dto.ExportResp, error)
+}
+type AccountLogic struct {
+	userRepo    *repo.UserRepo
+	accountRepo *repo.AccountRepo
+	email       *emailx.EmailX
+	user        *UserLogic
+}
+
+func NewAccountLogic() *AccountLogic {
+	return &AccountLogic{
+		userRepo:    repo.NewUserRepo(),
+		accountRepo: repo.NewAccountRepo(),
+		email:       emailx.NewEmailX(NewOutbox()),
+		user:        NewUserLogic(),
+	}
+}
+
+var _ IAccountLogic = (*AccountLogic)(nil)
+
+// GetDeleteAccountCode 向账号邮箱发送注销账号的验证码，用于忘记密码的用户
+func (al *AccountLogic) GetDeleteAccountCode(ctx context.Context, userID string) (dto.GetCodeResp, error) {
+	var resp dto.GetCodeResp
+	u, err := al.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	c := emailx.GenCode()
+	messageID, err := al.email.SendDeleteAccountCode(ctx, userID, u.Email, c)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return resp, ErrCodeGet
+	}
+	return newCodeResp(c, messageID), nil
+}
+
+// DeleteAccount 通过密码或邮箱验证码确认身份后注销账号，所有会话立即退出
+// 数据在宽限期后由 AccountPurger 彻底删除，宽限期内重新登录即取消注销
+func (al *AccountLogic) DeleteAccount(ctx context.Context, userID string, req dto.DeleteAccountReq) (dto.DeleteAccountResp, error) {
+	var resp dto.DeleteAccountResp
+	u, err := al.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	if req.Password != "" {
+		if err := al.user.checkPassword(ctx, u, req.Password, ErrPassword); err != nil {
+			return resp, err
+		}
+	} else if ok := al.email.VerifyCode(ctx, fmt.Sprintf(constant.DELETE_ACCOUNT_CODE_KEY, userID), req.Code); !ok {
+		return resp, ErrCodeVerify
+	}
+	deletedAt, err := al.accountRepo.SoftDelete(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	global.Logger(ctx).Infof("用户%s申请注销账号", userID)
+	days := int(constant.ACCOUNT_DELETE_GRACE.Hours() / 24)
+	resp.PurgeAt = deletedAt + constant.ACCOUNT_DELETE_GRACE.Milliseconds()
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "user.account_deleted", map[string]any{"days": days})
+	return resp, nil
+}
+
+// Export 导出用户表及相关表中保存的该用户的全部数据
+func (al *AccountLogic) Export(ctx context.Context, userID string) (dto.ExportResp, error) {
+	var resp dto.ExportResp
+	data, err := al.accountRepo.Export(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	resp.ExportedAt = time.Now().UnixMilli()
+	resp.User = dto.ExportUser{
+		ProfileResp:  newProfileResp(user.User(data.User)),
+		TokenVersion: data.User.TokenVersion,
+		DeletedAt:    data.User.DeletedAt,
+	}
+	resp.Sessions = make([]dto.ExportSession, 0, len(data.Sessions))
+	for _, s := range data.Sessions {
+		resp.Sessions = append(resp.Sessions, dto.ExportSession{
+			SessionID:  s.SessionID.String(),
+			UserAgent:  s.UserAgent,
+			IP:         s.Ip,
+			Ctime:      s.Ctime,
+			LastSeen:   s.LastSeen,
+			ExpireTime: s.ExpireTime,
+			Revoked:    s.Revoked,
+		})
+	}
+	resp.RefreshTokens = make([]dto.ExportRefreshToken, 0, len(data.RefreshTokens))
+	for _, t := range data.RefreshTokens {
+		resp.RefreshTokens = append(resp.RefreshTokens, dto.ExportRefreshToken{
+			FamilyID:   t.FamilyID.String(),
+			Ctime:      t.Ctime,
+			ExpireTime: t.ExpireTime,
+			Used:       t.Used,
+			Revoked:    t.Revoked,
+		})
+	}
+	resp.EmailChanges = make([]dto.ExportEmailChange, 0, len(data.EmailChanges))
+	for _, c := range data.EmailChanges {
+		resp.EmailChanges = append(resp.EmailChanges, dto.ExportEmailChange{
+			OldEmail:   c.OldEmail,
+			NewEmail:   c.NewEmail,
+			Ctime:      c.Ctime,
+			ExpireTime: c.ExpireTime,
+			Reverted:   c.Reverted,
+		})
+	}
+	resp.Emails = make([]dto.ExportEmail, 0, len(data.Emails))
+	for _, e := range data.Emails {
+		resp.Emails = append(resp.Emails, dto.ExportEmail{
+			MessageID: e.MessageID.String(),
+			Recipient: e.Recipient,
+			Template:  e.Template,
+			Locale:    e.Locale,
+			Subject:   e.Subject,
+			Status:    e.Status,
+			Attempts:  e.Attempts,
+			Ctime:     e.Ctime,
+			Utime:     e.Utime,
+		})
+	}
+	return resp, nil
+}
+
+// AccountPurger 定期彻底删除已过宽限期的注销账号，包括数据库中的数据和存储中的头像
+type AccountPurger struct {
+	accountRepo *repo.AccountRepo
+	storage     storagex.Storage
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func NewAccountPurger() *AccountPurger {
+	return &AccountPurger{
+		accountRepo: repo.NewAccountRepo(),
+		storage:     global.Storage,
+	}
+}
+
+// Start 启动后立即执行一次清理，之后每隔 ACCOUNT_PURGE_WAIT 执行一次
+func (ap *AccountPurger) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ap.cancel = cancel
+	ap.wg.Add(1)
+	go func() {
+		defer ap.wg.Done()
+		for {
+			ap.purge(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(constant.ACCOUNT_PURGE_WAIT):
+			}
+		}
+	}()
+}
+
+// Stop 停止清理，并等待正在进行的一批完成
+func (ap *AccountPurger) Stop() {
+	ap.cancel()
+	ap.wg.Wait()
+}
+
+// purge 分批删除，直到没有到期的账号
+func (ap *AccountPurger) purge(ctx context.Context) {
+	for ctx.Err() == nil {
+		before := time.Now().Add(-constant.ACCOUNT_DELETE_GRACE).UnixMilli()
+		userIDs, err := ap.accountRepo.Purge(ctx, before, constant.ACCOUNT_PURGE_BATCH)
+		if err != nil {
+			// 错误已经在 repo 层记录，等待下一次清理
+			return
+		}
+		for _, userID := range userIDs {
+			// 数据库中的记录已经删除，头像删除失败只记录日志
+			if err := ap.storage.DeletePrefix(ctx, fmt.Sprintf(constant.AVATAR_DIR, userID)); err != nil {
+				global.Logger(ctx).Errorf("用户%s的头像删除失败:%v", userID, err)
+			}
+		}
+		if len(userIDs) > 0 {
+			global.Logger(ctx).Infof("已彻底删除%d个注销账号", len(userIDs))
+		}
+		if len(userIDs) < constant.ACCOUNT_PURGE_BATCH {
+			return
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/constant"
+	"nurture/internal/dto"
+	"nurture/internal/global"
+	"nurture/internal/pkg/i18nx"
+	"nurture/internal/repo"
+	"nurture/internal/repo/admin"
+	"nurture/internal/repo/user"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type IAdminLogic interface {
+	ListUsers(ctx context.Context, req dto.ListUsersReq) (dto.ListUsersResp, error)
+	GetUser(ctx context.Context, req dto.AdminUserReq) (dto.AdminUserItem, error)
+	UpdateRole(ctx context.Context, adminID string, req dto.UpdateRoleReq) (dto.AdminUserActionResp, error)
+	SetDisabled(ctx context.Context, adminID string, req dto.AdminUserReq, disabled bool) (dto.AdminUserActionResp, error)
+	RequirePasswordReset(ctx context.Context, adminID string, req dto.AdminUserReq) (dto.AdminUserActionResp, error)
+}
+type AdminLogic struct {
+	adminRepo *repo.AdminRepo
+}
+
+func NewAdminLogic() *AdminLogic {
+	return &AdminLogic{
+		adminRepo: repo.NewAdminRepo(),
+	}
+}
+
+var _ IAdminLogic = (*AdminLogic)(nil)
+
+func (al *AdminLogic) ListUsers(ctx context.Context, req dto.ListUsersReq) (dto.ListUsersResp, error) {
+	var resp dto.ListUsersResp
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = constant.ADMIN_PAGE_SIZE
+	}
+	filter := repo.UserFilter{
+		Role:     req.Role,
+		Disabled: req.Disabled,
+		Deleted:  req.Deleted,
+	}
+	if keyword := strings.TrimSpace(req.Keyword); keyword != "" {
+		filter.Keyword = &keyword
+	}
+	users, total, err := al.adminRepo.ListUsers(ctx, filter, req.PageSize, (req.Page-1)*req.PageSize)
+	if err != nil {
+		return resp, ErrDefault
+	}
+	resp.Users = make([]dto.AdminUserItem, 0, len(users))
+	for _, u := range users {
+		resp.Users = append(resp.Users, newAdminUserItem(u))
+	}
+	resp.Total = total
+	resp.Page = req.Page
+	resp.PageSize = req.PageSize
+	return resp, nil
+}
+
+func (al *AdminLogic) GetUser(ctx context.Context, req dto.AdminUserReq) (dto.AdminUserItem, error) {
+	u, err := al.adminRepo.GetUser(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return dto.AdminUserItem{}, ErrUserNotExist
+		}
+		return dto.AdminUserItem{}, ErrDefault
+	}
+	return newAdminUserItem(u), nil
+}
+
+// UpdateRole 修改用户角色，用户刷新令牌后新角色生效
+// 不允许修改自己的角色，避免最后一个管理员误操作后无人可以管理
+func (al *AdminLogic) UpdateRole(ctx context.Context, adminID string, req dto.UpdateRoleReq) (dto.AdminUserActionResp, error) {
+	var resp dto.AdminUserActionResp
+	if isSelf(adminID, req.UserID) {
+		return resp, ErrAdminSelf
+	}
+	u, err := al.adminRepo.UpdateRole(ctx, req.UserID, req.Role)
+	if err != nil {
+		return resp, adminRepoErr(err)
+	}
+	global.Logger(ctx).Infof("管理员%s把用户%s的角色修改为%d", adminID, req.UserID, req.Role)
+	resp.User = newAdminUserItem(u)
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "user.role_updated", nil)
+	return resp, nil
+}
+
+// SetDisabled 禁用或启用账号，禁用后该用户立即退出所有设备且无法再登录
+func (al *AdminLogic) SetDisabled(ctx context.Context, adminID string, req dto.AdminUserReq, disabled bool) (dto.AdminUserActionResp, error) {
+	var resp dto.AdminUserActionResp
+	if isSelf(adminID, req.UserID) {
+		return resp, ErrAdminSelf
+	}
+	u, err := al.adminRepo.SetDisabled(ctx, req.UserID, disabled)
+	if err != nil {
+		return resp, adminRepoErr(err)
+	}
+	key := "user.enabled"
+	if disabled {
+		key = "user.disabled"
+	}
+	global.Logger(ctx).Infof("管理员%s把用户%s的禁用状态修改为%t", adminID, req.UserID, disabled)
+	resp.User = newAdminUserItem(u)
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), key, nil)
+	return resp, nil
+}
+
+// RequirePasswordReset 要求用户重置密码，该用户立即退出所有设备，通过邮箱重置密码前无法登录
+func (al *AdminLogic) RequirePasswordReset(ctx context.Context, adminID string, req dto.AdminUserReq) (dto.AdminUserActionResp, error) {
+	var resp dto.AdminUserActionResp
+	if isSelf(adminID, req.UserID) {
+		return resp, ErrAdminSelf
+	}
+	u, err := al.adminRepo.RequirePasswordReset(ctx, req.UserID)
+	if err != nil {
+		return resp, adminRepoErr(err)
+	}
+	global.Logger(ctx).Infof("管理员%s要求用户%s重置密码", adminID, req.UserID)
+	resp.User = newAdminUserItem(u)
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "user.password_reset_requested", nil)
+	return resp, nil
+}
+
+func adminRepoErr(err error) error {
+	if errors.Is(err, repo.ErrUserNotExist) {
+		return ErrUserNotExist
+	}
+	return ErrDefault
+}
+
+func newAdminUserItem(u admin.User) dto.AdminUserItem {
+	return dto.AdminUserItem{
+		ProfileResp:       newProfileResp(user.User(u)),
+		Disabled:          u.Disabled,
+		MustResetPassword: u.MustResetPassword,
+		DeletedAt:         u.DeletedAt,
+	}
+}
+
+// isSelf 按解析后的 UUID 比较，避免大小写或格式不同的同一个 ID 绕过检查
+func isSelf(adminID, userID string) bool {
+	a, err := uuid.Parse(adminID)
+	if err != nil {
+		return false
+	}
+	u, err := uuid.Parse(userID)
+	return err == nil && a == u
+}
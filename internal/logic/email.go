@@ -0,0 +1,141 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/constant"
+	"nurture/internal/dto"
+	"nurture/internal/pkg/emailx"
+	"nurture/internal/pkg/tplx"
+	"nurture/internal/repo"
+	"time"
+)
+
+type IEmailLogic interface {
+	GetEmailStatus(ctx context.Context, userID string, req dto.EmailStatusReq) (dto.EmailStatusResp, error)
+}
+type EmailLogic struct {
+	outboxRepo *repo.OutboxRepo
+	userRepo   *repo.UserRepo
+}
+
+func NewEmailLogic() *EmailLogic {
+	return &EmailLogic{
+		outboxRepo: repo.NewOutboxRepo(),
+		userRepo:   repo.NewUserRepo(),
+	}
+}
+
+var _ IEmailLogic = (*EmailLogic)(nil)
+
+// emailStatus 发件箱状态对外的名称
+var emailStatus = map[int16]string{
+	constant.EMAIL_PENDING: "pending",
+	constant.EMAIL_SENDING: "sending",
+	constant.EMAIL_SENT:    "sent",
+	constant.EMAIL_DEAD:    "dead",
+}
+
+// GetEmailStatus 查询邮件的投递状态，只返回状态不返回收件人和内容
+// 只能查询自己发起的邮件，或发往自己当前邮箱的邮件，其他邮件视为不存在
+func (el *EmailLogic) GetEmailStatus(ctx context.Context, userID string, req dto.EmailStatusReq) (dto.EmailStatusResp, error) {
+	var resp dto.EmailStatusResp
+	u, err := el.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	e, err := el.outboxRepo.GetOwnedEmail(ctx, req.MessageID, userID, u.Email)
+	if err != nil {
+		if errors.Is(err, repo.ErrEmailNotExist) {
+			return resp, ErrEmailNotExist
+		}
+		return resp, ErrDefault
+	}
+	resp.MessageID = e.MessageID.String()
+	resp.Status = emailStatus[e.Status]
+	resp.Attempts = e.Attempts
+	resp.Ctime = e.Ctime
+	resp.Utime = e.Utime
+	return resp, nil
+}
+
+// Outbox 基于 OutboxRepo 的邮件发件箱，供 emailx 入队和投递使用
+type Outbox struct {
+	outboxRepo *repo.OutboxRepo
+}
+
+func NewOutbox() *Outbox {
+	return &Outbox{
+		outboxRepo: repo.NewOutboxRepo(),
+	}
+}
+
+var _ emailx.Outbox = (*Outbox)(nil)
+
+func (o *Outbox) Enqueue(ctx context.Context, email emailx.OutboxEmail) error {
+	var expireAt int64
+	if !email.ExpireAt.IsZero() {
+		expireAt = email.ExpireAt.UnixMilli()
+	}
+	return o.outboxRepo.Enqueue(ctx, repo.OutboxEmail{
+		MessageID: email.MessageID,
+		UserID:    email.UserID,
+		To:        email.To,
+		Template:  email.Template,
+		Locale:    email.Locale,
+		Subject:   email.Message.Subject,
+		HTML:      email.Message.HTML,
+		Text:      email.Message.Text,
+		ExpireAt:  expireAt,
+	})
+}
+
+func (o *Outbox) Claim(ctx context.Context, batchSize int32, lease time.Duration) ([]emailx.OutboxEmail, error) {
+	rows, err := o.outboxRepo.Claim(ctx, batchSize, lease)
+	if err != nil {
+		return nil, err
+	}
+	emails := make([]emailx.OutboxEmail, 0, len(rows))
+	for _, row := range rows {
+		email := emailx.OutboxEmail{
+			ID:        row.ID,
+			MessageID: row.MessageID.String(),
+			To:        row.Recipient,
+			Template:  row.Template,
+			Locale:    row.Locale,
+			Message: tplx.Message{
+				Subject: row.Subject,
+				HTML:    row.Html,
+				Text:    row.Text,
+			},
+			Attempts: row.Attempts,
+		}
+		if row.UserID.Valid {
+			email.UserID = row.UserID.String()
+		}
+		if row.ExpireAt > 0 {
+			email.ExpireAt = time.UnixMilli(row.ExpireAt)
+		}
+		emails = append(emails, email)
+	}
+	return emails, nil
+}
+
+func (o *Outbox) MarkSent(ctx context.Context, id int64) error {
+	return o.outboxRepo.MarkSent(ctx, id)
+}
+
+func (o *Outbox) MarkRetry(ctx context.Context, id int64, next time.Time, reason string) error {
+	return o.outboxRepo.MarkRetry(ctx, id, next, reason)
+}
+
+func (o *Outbox) MarkDead(ctx context.Context, id int64, reason string) error {
+	return o.outboxRepo.MarkDead(ctx, id, reason)
+}
+
+func (o *Outbox) Release(ctx context.Context, id int64) error {
+	return o.outboxRepo.Release(ctx, id)
+}
@@ -1,24 +1,45 @@
 package logic
 
 import (
-	"errors"
 	"fmt"
+	"net/http"
 	"nurture/internal/constant"
+	"nurture/internal/pkg/errorx"
+	"nurture/internal/repo"
 )
 
 var (
-	ErrParamsType   = errors.New("参数格式错误")
-	ErrDefault      = errors.New("默认错误")
-	ErrFileOverSize = fmt.Errorf("文件大小不能超过%dMB", constant.FILE_MAX_SIZE/1024/1024)
-	ErrFileRead     = errors.New("文件读取失败")
+	ErrParamsType   = errorx.ErrParams
+	ErrDefault      = errorx.ErrInternal
+	ErrFileOverSize = errorx.New(40001, http.StatusRequestEntityTooLarge, "file.over_size", fmt.Sprintf("文件大小不能超过%dMB", constant.FILE_MAX_SIZE/1024/1024)).WithParams(map[string]any{"max": constant.FILE_MAX_SIZE / 1024 / 1024})
+	ErrFileRead     = errorx.New(40002, http.StatusBadRequest, "file.read", "文件读取失败")
+	ErrFileType     = errorx.New(40003, http.StatusUnsupportedMediaType, "file.type", "只支持 jpeg、png、gif、webp 格式的图片")
+	ErrImageTooBig  = errorx.New(40004, http.StatusBadRequest, "file.image_too_big", "图片分辨率过大")
+	ErrAvatarURL    = errorx.New(40005, http.StatusBadRequest, "file.avatar_invalid", "头像只能使用自己上传的图片")
+)
+
+// 与 repo 层相同的错误直接复用，业务码不能重复
+var (
+	ErrLoginWithFailedWay    = errorx.New(30001, http.StatusBadRequest, "user.login_type", "暂不支持这种登录方式")
+	ErrAccountOrPassword     = errorx.New(30002, http.StatusUnauthorized, "user.account_or_password", "账号或密码错误")
+	ErrEmail                 = errorx.New(30003, http.StatusBadRequest, "user.email", "邮箱错误")
+	ErrCodeGet               = errorx.New(30004, http.StatusInternalServerError, "user.code_get", "code获取失败")
+	ErrCodeVerify            = errorx.New(30005, http.StatusBadRequest, "user.code_verify", "验证码错误")
+	ErrEmailIsUsed           = repo.ErrEmailIsUsed
+	ErrAccountIsUsed         = repo.ErrAccountIsUsed
+	ErrUserNotExist          = repo.ErrUserNotExist
+	ErrAccountLocked         = errorx.New(30009, http.StatusLocked, "user.account_locked", "密码错误次数过多，账号已被临时锁定，请稍后再试")
+	ErrOldPassword           = errorx.New(30010, http.StatusBadRequest, "user.old_password", "原密码错误")
+	ErrEmailUnchanged        = errorx.New(30011, http.StatusBadRequest, "user.email_unchanged", "新邮箱与当前邮箱相同")
+	ErrEmailChangeInvalid    = repo.ErrEmailChangeInvalid
+	ErrPassword              = errorx.New(30013, http.StatusBadRequest, "user.password", "密码错误")
+	ErrAccountDisabled       = errorx.New(30014, http.StatusForbidden, "user.account_disabled", "账号已被禁用")
+	ErrPasswordResetRequired = errorx.New(30015, http.StatusForbidden, "user.password_reset_required", "为了账号安全，请先通过邮箱重置密码")
+	ErrAdminSelf             = errorx.New(30016, http.StatusBadRequest, "user.admin_self", "不能对自己的账号执行该操作")
+	ErrReauthLocked          = errorx.New(30017, http.StatusLocked, "user.reauth_locked", "密码错误次数过多，请稍后再试")
+	ErrRefreshTokenInvalid   = errorx.New(20006, http.StatusUnauthorized, "token.refresh_invalid", "登录已失效，请重新登录")
+	ErrSessionNotExist       = repo.ErrSessionNotExist
 )
 var (
-	ErrLoginWithFailedWay = errors.New("暂不支持这种登录方式")
-	ErrAccountOrPassword  = errors.New("账号或密码错误")
-	ErrEmail              = errors.New("邮箱错误")
-	ErrCodeGet            = errors.New("code获取失败")
-	ErrCodeVerify         = errors.New("验证码错误")
-	ErrEmailIsUsed        = errors.New("邮箱已经被使用")
-	ErrAccountIsUsed      = errors.New("账号已经被使用")
-	ErrUserNotExist       = errors.New("用户不存在")
+	ErrEmailNotExist = repo.ErrEmailNotExist
 )
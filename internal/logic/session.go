@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/dto"
+	"nurture/internal/global"
+	"nurture/internal/pkg/i18nx"
+	"nurture/internal/repo"
+)
+
+type ISessionLogic interface {
+	ListSessions(ctx context.Context, userID, currentSessionID string) (dto.ListSessionResp, error)
+	RevokeSession(ctx context.Context, userID string, req dto.RevokeSessionReq) (dto.RevokeSessionResp, error)
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) (dto.RevokeOtherSessionsResp, error)
+}
+type SessionLogic struct {
+	sessionRepo *repo.SessionRepo
+}
+
+func NewSessionLogic() *SessionLogic {
+	return &SessionLogic{
+		sessionRepo: repo.NewSessionRepo(),
+	}
+}
+
+var _ ISessionLogic = (*SessionLogic)(nil)
+
+func (sl *SessionLogic) ListSessions(ctx context.Context, userID, currentSessionID string) (dto.ListSessionResp, error) {
+	var resp dto.ListSessionResp
+	list, err := sl.sessionRepo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return resp, ErrDefault
+	}
+	resp.Sessions = make([]dto.SessionItem, 0, len(list))
+	for _, s := range list {
+		resp.Sessions = append(resp.Sessions, dto.SessionItem{
+			SessionID: s.SessionID.String(),
+			UserAgent: s.UserAgent,
+			IP:        s.Ip,
+			Ctime:     s.Ctime,
+			LastSeen:  s.LastSeen,
+			Current:   s.SessionID.String() == currentSessionID,
+		})
+	}
+	return resp, nil
+}
+
+func (sl *SessionLogic) RevokeSession(ctx context.Context, userID string, req dto.RevokeSessionReq) (dto.RevokeSessionResp, error) {
+	var resp dto.RevokeSessionResp
+	err := sl.sessionRepo.RevokeSession(ctx, userID, req.SessionID)
+	if err != nil {
+		if errors.Is(err, repo.ErrSessionNotExist) {
+			return resp, ErrSessionNotExist
+		}
+		return resp, ErrDefault
+	}
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "session.revoke_success", nil)
+	return resp, nil
+}
+
+func (sl *SessionLogic) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) (dto.RevokeOtherSessionsResp, error) {
+	var resp dto.RevokeOtherSessionsResp
+	count, err := sl.sessionRepo.RevokeOtherSessions(ctx, userID, currentSessionID)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return resp, ErrDefault
+	}
+	resp.Count = count
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "session.revoke_others_success", nil)
+	return resp, nil
+}
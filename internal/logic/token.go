@@ -0,0 +1,180 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/config"
+	"nurture/internal/dto"
+	"nurture/internal/global"
+	"nurture/internal/pkg/i18nx"
+	"nurture/internal/pkg/jwtx"
+	"nurture/internal/repo"
+	"nurture/internal/repo/user"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ITokenLogic interface {
+	Refresh(ctx context.Context, req dto.RefreshTokenReq) (dto.RefreshTokenResp, error)
+	Logout(ctx context.Context, req dto.LogoutReq) (dto.LogoutResp, error)
+}
+type TokenLogic struct {
+	tokenRepo   *repo.TokenRepo
+	userRepo    *repo.UserRepo
+	sessionRepo *repo.SessionRepo
+}
+
+func NewTokenLogic() *TokenLogic {
+	return &TokenLogic{
+		tokenRepo:   repo.NewTokenRepo(),
+		userRepo:    repo.NewUserRepo(),
+		sessionRepo: repo.NewSessionRepo(),
+	}
+}
+
+var _ ITokenLogic = (*TokenLogic)(nil)
+
+// Issue 为一次新的登录创建会话并签发访问令牌和刷新令牌，刷新令牌的家族ID即会话ID
+func (tl *TokenLogic) Issue(ctx context.Context, u user.User, client dto.ClientInfo) (accessToken, refreshToken string, err error) {
+	refreshToken, hash, err := jwtx.GenRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+	sessionID := uuid.NewString()
+	expireTime := refreshExpireTime()
+	err = tl.sessionRepo.CreateSession(ctx, sessionID, u.UserID.String(), client.UserAgent, client.IP, expireTime)
+	if err != nil {
+		return "", "", err
+	}
+	err = tl.tokenRepo.CreateRefreshToken(ctx, hash, sessionID, u.UserID.String(), expireTime)
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, err = genAccessToken(u, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// Refresh 使用刷新令牌换取新的令牌对，旧的刷新令牌随即失效
+// 已经轮换过的刷新令牌再次出现说明可能被盗用，此时吊销整个家族
+func (tl *TokenLogic) Refresh(ctx context.Context, req dto.RefreshTokenReq) (dto.RefreshTokenResp, error) {
+	var resp dto.RefreshTokenResp
+	old, err := tl.tokenRepo.GetRefreshToken(ctx, jwtx.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, repo.ErrTokenNotExist) {
+			return resp, ErrRefreshTokenInvalid
+		}
+		return resp, ErrDefault
+	}
+	if old.Revoked || time.Now().UnixMilli() > old.ExpireTime {
+		return resp, ErrRefreshTokenInvalid
+	}
+	if old.Used {
+		tl.revokeReusedFamily(ctx, old.FamilyID.String(), old.UserID.String())
+		return resp, ErrRefreshTokenInvalid
+	}
+	// 会话已经注销或不存在时令牌家族随之失效，吊销可能因会话行缺失而遗漏的令牌
+	s, err := tl.sessionRepo.GetSession(ctx, old.FamilyID.String())
+	if err != nil && !errors.Is(err, repo.ErrSessionNotExist) {
+		return resp, ErrDefault
+	}
+	if err != nil || s.Revoked || s.UserID != old.UserID {
+		if err := tl.tokenRepo.RevokeFamily(ctx, old.FamilyID.String()); err != nil {
+			global.Logger(ctx).Error(err)
+		}
+		return resp, ErrRefreshTokenInvalid
+	}
+	u, err := tl.userRepo.GetUserByID(ctx, old.UserID.String())
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrRefreshTokenInvalid
+		}
+		return resp, ErrDefault
+	}
+	refreshToken, hash, err := jwtx.GenRefreshToken()
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return resp, ErrDefault
+	}
+	expireTime := refreshExpireTime()
+	err = tl.tokenRepo.RotateRefreshToken(ctx, old, hash, expireTime)
+	if err != nil {
+		if errors.Is(err, repo.ErrTokenUsed) {
+			// 并发请求中另一个已经完成了轮换，同样按重放处理
+			tl.revokeReusedFamily(ctx, old.FamilyID.String(), old.UserID.String())
+			return resp, ErrRefreshTokenInvalid
+		}
+		return resp, ErrDefault
+	}
+	// 会话随刷新令牌一起续期，失败只影响会话列表中的过期时间
+	_ = tl.sessionRepo.ExtendSession(ctx, old.FamilyID.String(), expireTime)
+	accessToken, err := genAccessToken(u, old.FamilyID.String())
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return resp, ErrDefault
+	}
+	resp.Token = accessToken
+	resp.RefreshToken = refreshToken
+	resp.ExpireIn = config.Conf.Auth.AccessExpire
+	return resp, nil
+}
+
+// Logout 注销刷新令牌所属的会话并吊销其令牌家族，即退出当前这次登录
+func (tl *TokenLogic) Logout(ctx context.Context, req dto.LogoutReq) (dto.LogoutResp, error) {
+	var resp dto.LogoutResp
+	t, err := tl.tokenRepo.GetRefreshToken(ctx, jwtx.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, repo.ErrTokenNotExist) {
+			return resp, ErrRefreshTokenInvalid
+		}
+		return resp, ErrDefault
+	}
+	err = tl.sessionRepo.RevokeSession(ctx, t.UserID.String(), t.FamilyID.String())
+	if err != nil && !errors.Is(err, repo.ErrSessionNotExist) {
+		return resp, ErrDefault
+	}
+	// 会话不存在时 RevokeSession 不会吊销令牌家族，这里再单独吊销一次，保证退出后刷新令牌不可用
+	if err := tl.tokenRepo.RevokeFamily(ctx, t.FamilyID.String()); err != nil {
+		return resp, ErrDefault
+	}
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "token.logout_success", nil)
+	return resp, nil
+}
+
+// revokeReusedFamily 刷新令牌被重放时注销整个会话，持有该会话访问令牌的请求也会立即失效
+func (tl *TokenLogic) revokeReusedFamily(ctx context.Context, familyID, userID string) {
+	global.Logger(ctx).Warnf("用户%s的刷新令牌被重复使用，注销会话%s", userID, familyID)
+	err := tl.sessionRepo.RevokeSession(ctx, userID, familyID)
+	if err != nil && !errors.Is(err, repo.ErrSessionNotExist) {
+		global.Logger(ctx).Error(err)
+	}
+	// 会话已经注销时仍然确保令牌家族被吊销
+	if err := tl.tokenRepo.RevokeFamily(ctx, familyID); err != nil {
+		global.Logger(ctx).Error(err)
+	}
+}
+
+// Reissue 为已有的会话重新签发访问令牌，用于令牌版本变化后让当前会话继续有效
+func (tl *TokenLogic) Reissue(ctx context.Context, userID, sessionID string) (string, error) {
+	u, err := tl.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return genAccessToken(u, sessionID)
+}
+
+func genAccessToken(u user.User, sessionID string) (string, error) {
+	return jwtx.GenToken(jwtx.Claims{
+		UserID:       u.UserID.String(),
+		Role:         jwtx.Role(u.Role),
+		SessionID:    sessionID,
+		TokenVersion: u.TokenVersion,
+	})
+}
+
+func refreshExpireTime() int64 {
+	return time.Now().Add(time.Duration(config.Conf.Auth.RefreshExpire) * time.Second).UnixMilli()
+}
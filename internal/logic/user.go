@@ -1,15 +1,29 @@
 package logic
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"nurture/internal/config"
 	"nurture/internal/constant"
 	"nurture/internal/dto"
 	"nurture/internal/global"
 	"nurture/internal/pkg/emailx"
+	"nurture/internal/pkg/hashx"
+	"nurture/internal/pkg/i18nx"
+	"nurture/internal/pkg/imagex"
 	"nurture/internal/pkg/jwtx"
+	"nurture/internal/pkg/lockx"
+	"nurture/internal/pkg/metricx"
+	"nurture/internal/pkg/storagex"
 	"nurture/internal/repo"
+	"nurture/internal/repo/user"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -21,136 +35,630 @@ type IUserLogic interface {
 	GetRegisterCode(ctx context.Context, req dto.GetCodeReq) (dto.GetCodeResp, error)
 	GetResetCode(ctx context.Context, req dto.GetCodeReq) (dto.GetCodeResp, error)
 	ResetPassword(ctx context.Context, req dto.ResetPasswordReq) (dto.ResetPasswordResp, error)
+	UpdateLocale(ctx context.Context, userID string, req dto.UpdateLocaleReq) (dto.UpdateLocaleResp, error)
+	GetProfile(ctx context.Context, userID string) (dto.ProfileResp, error)
+	UpdateProfile(ctx context.Context, userID string, req dto.UpdateProfileReq) (dto.ProfileResp, error)
+	ChangePassword(ctx context.Context, userID, sessionID string, req dto.ChangePasswordReq) (dto.ChangePasswordResp, error)
+	UploadAvatar(ctx context.Context, userID string, file io.Reader) (dto.UploadAvatarResp, error)
+	GetChangeEmailCode(ctx context.Context, userID string, req dto.GetCodeReq) (dto.GetCodeResp, error)
+	ChangeEmail(ctx context.Context, userID string, req dto.ChangeEmailReq) (dto.ChangeEmailResp, error)
+	RevertEmail(ctx context.Context, req dto.RevertEmailReq) (dto.RevertEmailResp, error)
 }
 type UserLogic struct {
-	userRepo *repo.UserRepo
-	email    *emailx.EmailX
+	userRepo    *repo.UserRepo
+	accountRepo *repo.AccountRepo
+	email       *emailx.EmailX
+	token       *TokenLogic
+	locker      lockx.Locker
+	storage     storagex.Storage
 }
 
 func NewUserLogic() *UserLogic {
 	return &UserLogic{
-		userRepo: repo.NewUserRepo(),
-		email:    emailx.NewEmailX(),
+		userRepo:    repo.NewUserRepo(),
+		accountRepo: repo.NewAccountRepo(),
+		email:       emailx.NewEmailX(NewOutbox()),
+		token:       NewTokenLogic(),
+		locker:      global.Locker,
+		storage:     global.Storage,
 	}
 }
 
 var _ IUserLogic = (*UserLogic)(nil)
 
+// Login 登录并记录登录指标
 func (ul *UserLogic) Login(ctx context.Context, req dto.LoginReq) (dto.LoginResp, error) {
+	resp, err := ul.login(ctx, req)
+	loginType := req.LoginType
+	if loginType != constant.LOGIN_WITH_ACCOUNT && loginType != constant.LOGIN_WITH_EMAIL {
+		// 登录方式来自请求，未知的值不作为指标标签
+		loginType = "unknown"
+	}
+	metricx.Login(loginType, err)
+	return resp, err
+}
+
+func (ul *UserLogic) login(ctx context.Context, req dto.LoginReq) (dto.LoginResp, error) {
 	var resp dto.LoginResp
 	switch req.LoginType {
 	case constant.LOGIN_WITH_ACCOUNT:
-		data, err := ul.userRepo.LoginWithAccount(ctx, req.Account, req.Password)
+		guardKey := fmt.Sprintf(constant.LOGIN_GUARD_KEY, req.Account)
+		if locked, err := ul.locker.Locked(ctx, guardKey); err != nil {
+			global.Logger(ctx).Error(err)
+		} else if locked > 0 {
+			return resp, ErrAccountLocked
+		}
+		data, err := ul.userRepo.LoginWithAccount(ctx, req.Account)
 		if err != nil {
+			// 账号不存在也计入失败次数并做一次哈希校验，避免通过锁定行为或响应时间区分账号是否存在
+			hashx.VerifyDummy(req.Password)
+			ul.loginFailed(ctx, guardKey, req.Account)
 			return resp, ErrAccountOrPassword
 		}
-		token, err := jwtx.GenToken(jwtx.Claims{
-			UserID: data.UserID.String(),
-			Role:   jwtx.Role(data.Role),
-		})
+		ok, rehash, err := hashx.VerifyPassword(req.Password, data.Password)
+		if err != nil {
+			global.Logger(ctx).Error(err)
+			return resp, ErrDefault
+		}
+		if !ok {
+			ul.loginFailed(ctx, guardKey, req.Account)
+			return resp, ErrAccountOrPassword
+		}
+		if err := ul.locker.Reset(ctx, guardKey); err != nil {
+			global.Logger(ctx).Error(err)
+		}
+		if err := checkLoginState(data); err != nil {
+			return resp, err
+		}
+		if rehash {
+			ul.rehashPassword(ctx, data.UserID.String(), req.Password)
+		}
+		if err := ul.restoreDeleted(ctx, data); err != nil {
+			return resp, err
+		}
+		token, refreshToken, err := ul.token.Issue(ctx, data, req.Client)
 		if err != nil {
-			global.Log.Error(err)
+			global.Logger(ctx).Error(err)
 			return resp, ErrDefault
 		}
 		resp.Username = data.Username
 		resp.Avatar = data.Avatar
+		resp.Locale = userLocale(ctx, data.Locale)
 		resp.Token = token
+		resp.RefreshToken = refreshToken
+		resp.ExpireIn = config.Conf.Auth.AccessExpire
 		return resp, nil
 	case constant.LOGIN_WITH_EMAIL:
-		if ok := ul.email.VerifyCode(fmt.Sprintf(constant.LOGIN_CODE_KEY, req.Email), req.Code); !ok {
+		if ok := ul.email.VerifyCode(ctx, fmt.Sprintf(constant.LOGIN_CODE_KEY, req.Email), req.Code); !ok {
 			return resp, ErrCodeVerify
 		}
 		data, err := ul.userRepo.LoginWithEmail(ctx, req.Email)
 		if err != nil {
 			return resp, ErrEmail
 		}
-		token, err := jwtx.GenToken(jwtx.Claims{
-			UserID: data.UserID.String(),
-			Role:   jwtx.Role(data.Role),
-		})
+		if err := checkLoginState(data); err != nil {
+			return resp, err
+		}
+		if err := ul.restoreDeleted(ctx, data); err != nil {
+			return resp, err
+		}
+		token, refreshToken, err := ul.token.Issue(ctx, data, req.Client)
 		if err != nil {
-			global.Log.Error(err)
+			global.Logger(ctx).Error(err)
 			return resp, ErrDefault
 		}
 		resp.Username = data.Username
 		resp.Avatar = data.Avatar
+		resp.Locale = userLocale(ctx, data.Locale)
 		resp.Token = token
+		resp.RefreshToken = refreshToken
+		resp.ExpireIn = config.Conf.Auth.AccessExpire
 		return resp, nil
 	default:
-		global.Log.Warnf("错误的登录方式:%s", req.LoginType)
+		global.Logger(ctx).Warnf("错误的登录方式:%s", req.LoginType)
 		return resp, ErrLoginWithFailedWay
 	}
 }
 
+// Register 注册并记录注册指标
 func (ul *UserLogic) Register(ctx context.Context, req dto.RegisterReq) (dto.RegisterResp, error) {
+	resp, err := ul.register(ctx, req)
+	metricx.Registration(err)
+	return resp, err
+}
+
+func (ul *UserLogic) register(ctx context.Context, req dto.RegisterReq) (dto.RegisterResp, error) {
 	var resp dto.RegisterResp
-	if ok := ul.email.VerifyCode(fmt.Sprintf(constant.REGISTER_CODE_KEY, req.Email), req.Code); !ok {
+	if ok := ul.email.VerifyCode(ctx, fmt.Sprintf(constant.REGISTER_CODE_KEY, req.Email), req.Code); !ok {
 		return resp, ErrCodeVerify
 	}
-	err := ul.userRepo.Register(ctx, uuid.NewString(), req.Username, req.Email, req.Account, req.Password)
+	password, err := hashx.HashPassword(req.Password)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return resp, ErrDefault
+	}
+	// 以注册时协商出的语言作为用户的偏好语言
+	err = ul.userRepo.Register(ctx, uuid.NewString(), req.Username, req.Email, req.Account, password, i18nx.FromContext(ctx))
 	if err != nil {
 		if errors.Is(err, repo.ErrEmailIsUsed) {
 			return resp, ErrEmailIsUsed
 		} else if errors.Is(err, repo.ErrAccountIsUsed) {
 			return resp, ErrAccountIsUsed
 		} else {
-			global.Log.Error(err)
+			global.Logger(ctx).Error(err)
 			return resp, ErrDefault
 		}
 	}
-	resp.Message = "用户注册成功！"
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "user.register_success", nil)
 	return resp, nil
 }
 
 func (ul *UserLogic) ResetPassword(ctx context.Context, req dto.ResetPasswordReq) (dto.ResetPasswordResp, error) {
 	var resp dto.ResetPasswordResp
-	if ok := ul.email.VerifyCode(fmt.Sprintf(constant.RESET_PWD_CODE_KEY, req.Email), req.Code); !ok {
+	if ok := ul.email.VerifyCode(ctx, fmt.Sprintf(constant.RESET_PWD_CODE_KEY, req.Email), req.Code); !ok {
 		return resp, ErrCodeVerify
 	}
-	err := ul.userRepo.ResetPassword(ctx, req.Email, req.NewPassword)
+	password, err := hashx.HashPassword(req.NewPassword)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return resp, ErrDefault
+	}
+	err = ul.userRepo.ResetPassword(ctx, req.Email, password)
 	if err != nil {
 		if errors.Is(err, repo.ErrUserNotExist) {
 			return resp, ErrUserNotExist
 		}
-		global.Log.Error(err)
+		global.Logger(ctx).Error(err)
 		return resp, ErrDefault
 	}
-	resp.Message = "重置密码成功！"
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "user.reset_password_success", nil)
 	return resp, nil
 }
 
+// loginFailed 记录一次密码错误，连续错误达到阈值时锁定 guardKey
+func (ul *UserLogic) loginFailed(ctx context.Context, guardKey, account string) {
+	d, err := ul.locker.Fail(ctx, guardKey)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return
+	}
+	if d > 0 {
+		global.Logger(ctx).Warnf("账号%s连续输错密码，锁定%s", account, d)
+	}
+}
+
+// checkPassword 已登录的用户重新输入密码确认身份，输错时返回 wrong
+// 输错达到阈值后暂停确认身份，防止持有访问令牌的人暴力猜测密码
+// 失败计数与登录分开，避免确认身份输错把账号的登录也锁住
+func (ul *UserLogic) checkPassword(ctx context.Context, u user.User, password string, wrong error) error {
+	guardKey := fmt.Sprintf(constant.REAUTH_GUARD_KEY, u.UserID.String())
+	if locked, err := ul.locker.Locked(ctx, guardKey); err != nil {
+		global.Logger(ctx).Error(err)
+	} else if locked > 0 {
+		return ErrReauthLocked
+	}
+	ok, _, err := hashx.VerifyPassword(password, u.Password)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if !ok {
+		ul.loginFailed(ctx, guardKey, u.Account)
+		return wrong
+	}
+	if err := ul.locker.Reset(ctx, guardKey); err != nil {
+		global.Logger(ctx).Error(err)
+	}
+	return nil
+}
+
+// checkLoginState 身份验证通过后检查账号是否允许登录，在验证之后检查避免泄露账号状态
+func checkLoginState(u user.User) error {
+	if u.Disabled {
+		return ErrAccountDisabled
+	}
+	if u.MustResetPassword {
+		return ErrPasswordResetRequired
+	}
+	return nil
+}
+
+// restoreDeleted 宽限期内重新登录时取消注销
+// 取消失败时本次登录也失败，否则账号会在登录状态下被彻底删除
+func (ul *UserLogic) restoreDeleted(ctx context.Context, u user.User) error {
+	if u.DeletedAt == 0 {
+		return nil
+	}
+	if err := ul.accountRepo.Restore(ctx, u.UserID.String()); err != nil {
+		global.Logger(ctx).Errorf("用户%s取消注销失败:%v", u.UserID.String(), err)
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return ErrUserNotExist
+		}
+		return ErrDefault
+	}
+	global.Logger(ctx).Infof("用户%s在宽限期内重新登录，已取消注销", u.UserID.String())
+	return nil
+}
+
+// rehashPassword 将明文或参数过时的密码重新哈希后写回，失败不影响本次登录
+func (ul *UserLogic) rehashPassword(ctx context.Context, userID, password string) {
+	hashed, err := hashx.HashPassword(password)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return
+	}
+	if err := ul.userRepo.UpdatePasswordByID(ctx, userID, hashed); err != nil {
+		global.Logger(ctx).Errorf("用户%s密码重新哈希失败:%v", userID, err)
+	}
+}
+
+// GetLoginCode 发送登录验证码，未登录的接口不查询邮箱对应的用户，邮件语言取 Accept-Language
+// 按邮箱查询偏好语言会通过响应时间暴露邮箱是否已注册
 func (ul *UserLogic) GetLoginCode(ctx context.Context, req dto.GetCodeReq) (dto.GetCodeResp, error) {
 	var resp dto.GetCodeResp
 	c := emailx.GenCode()
-	err := ul.email.SendLoginCode(ctx, req.Email, c)
+	messageID, err := ul.email.SendLoginCode(ctx, req.Email, c)
 	if err != nil {
-		global.Log.Error(err)
+		global.Logger(ctx).Error(err)
 		return resp, ErrCodeGet
 	}
-	resp.Code = c
-	return resp, nil
+	return newCodeResp(c, messageID), nil
 }
 
 func (ul *UserLogic) GetRegisterCode(ctx context.Context, req dto.GetCodeReq) (dto.GetCodeResp, error) {
 	var resp dto.GetCodeResp
 	c := emailx.GenCode()
-	err := ul.email.SendRegisterCode(ctx, req.Email, c)
+	messageID, err := ul.email.SendRegisterCode(ctx, req.Email, c)
 	if err != nil {
-		global.Log.Error(err)
+		global.Logger(ctx).Error(err)
 		return resp, ErrCodeGet
 	}
-	resp.Code = c
-	return resp, nil
+	return newCodeResp(c, messageID), nil
 }
 
 func (ul *UserLogic) GetResetCode(ctx context.Context, req dto.GetCodeReq) (dto.GetCodeResp, error) {
 	var resp dto.GetCodeResp
 	c := emailx.GenCode()
-	err := ul.email.SendResetPwdCode(ctx, req.Email, c)
+	messageID, err := ul.email.SendResetPwdCode(ctx, req.Email, c)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return resp, ErrCodeGet
+	}
+	return newCodeResp(c, messageID), nil
+}
+
+// UpdateLocale 修改用户的偏好语言，之后的响应和邮件都使用该语言
+func (ul *UserLogic) UpdateLocale(ctx context.Context, userID string, req dto.UpdateLocaleReq) (dto.UpdateLocaleResp, error) {
+	var resp dto.UpdateLocaleResp
+	locale, ok := i18nx.Normalize(req.Locale)
+	if !ok {
+		return resp, ErrParamsType
+	}
+	if err := ul.userRepo.UpdateLocaleByID(ctx, userID, locale); err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	resp.Locale = locale
+	resp.Message = i18nx.T(locale, "user.locale_updated", nil)
+	return resp, nil
+}
+
+func (ul *UserLogic) GetProfile(ctx context.Context, userID string) (dto.ProfileResp, error) {
+	u, err := ul.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return dto.ProfileResp{}, ErrUserNotExist
+		}
+		return dto.ProfileResp{}, ErrDefault
+	}
+	return newProfileResp(u), nil
+}
+
+func (ul *UserLogic) UpdateProfile(ctx context.Context, userID string, req dto.UpdateProfileReq) (dto.ProfileResp, error) {
+	if req.Username != nil {
+		username := strings.TrimSpace(*req.Username)
+		if username == "" {
+			return dto.ProfileResp{}, ErrParamsType
+		}
+		req.Username = &username
+	}
+	if req.Username == nil && req.Avatar == nil {
+		return dto.ProfileResp{}, ErrParamsType
+	}
+	// 头像只能清空或者使用自己上传的图片，不能指向任意地址
+	var oldAvatar string
+	if req.Avatar != nil {
+		if *req.Avatar != "" {
+			if _, ok := ul.avatarName(userID, *req.Avatar); !ok {
+				return dto.ProfileResp{}, ErrAvatarURL
+			}
+		}
+		old, err := ul.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, repo.ErrUserNotExist) {
+				return dto.ProfileResp{}, ErrUserNotExist
+			}
+			return dto.ProfileResp{}, ErrDefault
+		}
+		oldAvatar = old.Avatar
+	}
+	u, err := ul.userRepo.UpdateProfile(ctx, userID, req.Username, req.Avatar)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return dto.ProfileResp{}, ErrUserNotExist
+		}
+		return dto.ProfileResp{}, ErrDefault
+	}
+	if req.Avatar != nil {
+		ul.deleteAvatar(ctx, userID, oldAvatar, u.Avatar)
+	}
+	return newProfileResp(u), nil
+}
+
+// ChangePassword 校验原密码后修改密码，其他会话全部退出，当前会话换发新的访问令牌
+func (ul *UserLogic) ChangePassword(ctx context.Context, userID, sessionID string, req dto.ChangePasswordReq) (dto.ChangePasswordResp, error) {
+	var resp dto.ChangePasswordResp
+	u, err := ul.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	if err := ul.checkPassword(ctx, u, req.OldPassword, ErrOldPassword); err != nil {
+		return resp, err
+	}
+	password, err := hashx.HashPassword(req.NewPassword)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return resp, ErrDefault
+	}
+	if err := ul.userRepo.ChangePassword(ctx, userID, password, sessionID); err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	token, err := ul.token.Reissue(ctx, userID, sessionID)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return resp, ErrDefault
+	}
+	resp.Token = token
+	resp.ExpireIn = config.Conf.Auth.AccessExpire
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "user.password_changed", nil)
+	return resp, nil
+}
+
+// UploadAvatar 把上传的图片裁剪为正方形并重新编码为各标准尺寸的 JPEG，保存后更新用户头像
+func (ul *UserLogic) UploadAvatar(ctx context.Context, userID string, file io.Reader) (dto.UploadAvatarResp, error) {
+	var resp dto.UploadAvatarResp
+	data, err := io.ReadAll(io.LimitReader(file, constant.FILE_MAX_SIZE+1))
+	if err != nil {
+		return resp, ErrFileRead
+	}
+	if len(data) > constant.FILE_MAX_SIZE {
+		return resp, ErrFileOverSize
+	}
+	img, err := imagex.Decode(data)
+	if err != nil {
+		if errors.Is(err, imagex.ErrTooManyPixels) {
+			return resp, ErrImageTooBig
+		}
+		return resp, ErrFileType
+	}
+	name := uuid.NewString()
+	resp.Avatars = make(map[string]string, 2)
+	for _, size := range avatarSizes {
+		var buf bytes.Buffer
+		if err := imagex.EncodeJPEG(&buf, imagex.Square(img, size)); err != nil {
+			global.Logger(ctx).Error(err)
+			return resp, ErrDefault
+		}
+		key := fmt.Sprintf(constant.AVATAR_KEY, userID, name, size)
+		url, err := ul.storage.Put(ctx, key, &buf, int64(buf.Len()), "image/jpeg")
+		if err != nil {
+			global.Logger(ctx).Error(err)
+			return resp, ErrDefault
+		}
+		resp.Avatars[strconv.Itoa(size)] = url
+	}
+	resp.Avatar = resp.Avatars[strconv.Itoa(constant.AVATAR_SIZE)]
+	old, err := ul.userRepo.UpdateAvatarByID(ctx, userID, resp.Avatar)
+	if err != nil {
+		ul.deleteAvatar(ctx, userID, resp.Avatar, "")
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	ul.deleteAvatar(ctx, userID, old, resp.Avatar)
+	return resp, nil
+}
+
+// avatarSizes 上传头像时生成的所有尺寸
+var avatarSizes = []int{constant.AVATAR_SIZE, constant.AVATAR_SMALL_SIZE}
+
+// avatarName 从头像地址解析出上传时生成的文件名，不是该用户上传的头像时返回 false
+func (ul *UserLogic) avatarName(userID, url string) (string, bool) {
+	key, ok := ul.storage.Key(url)
+	if !ok {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(key, fmt.Sprintf(constant.AVATAR_DIR, userID)+"/")
+	if !ok {
+		return "", false
+	}
+	name, _, _ := strings.Cut(rest, "_")
+	if _, err := uuid.Parse(name); err != nil {
+		return "", false
+	}
+	for _, size := range avatarSizes {
+		if key == fmt.Sprintf(constant.AVATAR_KEY, userID, name, size) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// deleteAvatar 删除不再使用的旧头像的所有尺寸，old 与 current 是同一次上传时不删除
+// 删除失败只记录日志，注销账号时会整体删除头像目录
+func (ul *UserLogic) deleteAvatar(ctx context.Context, userID, old, current string) {
+	name, ok := ul.avatarName(userID, old)
+	if !ok {
+		return
+	}
+	if cur, ok := ul.avatarName(userID, current); ok && cur == name {
+		return
+	}
+	for _, size := range avatarSizes {
+		if err := ul.storage.Delete(ctx, fmt.Sprintf(constant.AVATAR_KEY, userID, name, size)); err != nil {
+			global.Logger(ctx).Error(err)
+		}
+	}
+}
+
+// GetChangeEmailCode 向新邮箱发送修改邮箱的验证码，新邮箱需要未被其他账号使用
+func (ul *UserLogic) GetChangeEmailCode(ctx context.Context, userID string, req dto.GetCodeReq) (dto.GetCodeResp, error) {
+	var resp dto.GetCodeResp
+	u, err := ul.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	if strings.EqualFold(u.Email, req.Email) {
+		return resp, ErrEmailUnchanged
+	}
+	if _, err := ul.userRepo.LoginWithEmail(ctx, req.Email); err == nil {
+		return resp, ErrEmailIsUsed
+	}
+	c := emailx.GenCode()
+	messageID, err := ul.email.SendChangeEmailCode(ctx, userID, req.Email, c)
 	if err != nil {
-		global.Log.Error(err)
+		global.Logger(ctx).Error(err)
 		return resp, ErrCodeGet
 	}
-	resp.Code = c
+	return newCodeResp(c, messageID), nil
+}
+
+// ChangeEmail 校验新邮箱收到的验证码后修改邮箱，并向旧邮箱发送带撤销链接的通知
+// 撤销链接用于账号被盗用时找回：撤销后邮箱改回旧邮箱，所有会话退出登录
+func (ul *UserLogic) ChangeEmail(ctx context.Context, userID string, req dto.ChangeEmailReq) (dto.ChangeEmailResp, error) {
+	var resp dto.ChangeEmailResp
+	if ok := ul.email.VerifyCode(ctx, fmt.Sprintf(constant.CHANGE_EMAIL_CODE_KEY, userID, req.Email), req.Code); !ok {
+		return resp, ErrCodeVerify
+	}
+	u, err := ul.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	if strings.EqualFold(u.Email, req.Email) {
+		return resp, ErrEmailUnchanged
+	}
+	// 撤销令牌与刷新令牌一样只保存摘要
+	revertToken, revertHash, err := jwtx.GenRefreshToken()
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return resp, ErrDefault
+	}
+	expireTime := time.Now().Add(constant.EMAIL_REVERT_TTL).UnixMilli()
+	if err := ul.userRepo.ChangeEmail(ctx, userID, u.Email, req.Email, revertHash, expireTime); err != nil {
+		if errors.Is(err, repo.ErrEmailIsUsed) {
+			return resp, ErrEmailIsUsed
+		} else if errors.Is(err, repo.ErrUserNotExist) {
+			return resp, ErrUserNotExist
+		}
+		return resp, ErrDefault
+	}
+	// 邮箱已经修改成功，通知发送失败只记录日志
+	if _, err := ul.email.SendToUser(ctx, userID, u.Email, "email_changed", map[string]any{
+		"OldEmail": u.Email,
+		"NewEmail": req.Email,
+		"Token":    revertToken,
+		"Link":     revertLink(revertToken),
+		"Days":     int(constant.EMAIL_REVERT_TTL.Hours() / 24),
+	}); err != nil {
+		global.Logger(ctx).Errorf("用户%s修改邮箱的通知发送失败:%v", userID, err)
+	}
+	resp.Email = req.Email
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "user.email_changed", nil)
+	return resp, nil
+}
+
+// RevertEmail 通过旧邮箱收到的撤销令牌恢复旧邮箱，不需要登录
+func (ul *UserLogic) RevertEmail(ctx context.Context, req dto.RevertEmailReq) (dto.RevertEmailResp, error) {
+	var resp dto.RevertEmailResp
+	change, err := ul.userRepo.RevertEmail(ctx, jwtx.HashRefreshToken(req.Token))
+	if err != nil {
+		if errors.Is(err, repo.ErrEmailChangeInvalid) {
+			return resp, ErrEmailChangeInvalid
+		} else if errors.Is(err, repo.ErrEmailIsUsed) {
+			return resp, ErrEmailIsUsed
+		}
+		return resp, ErrDefault
+	}
+	global.Logger(ctx).Warnf("用户%s撤销了邮箱修改，邮箱恢复为%s", change.UserID.String(), change.OldEmail)
+	resp.Email = change.OldEmail
+	resp.Message = i18nx.T(i18nx.FromContext(ctx), "user.email_reverted", nil)
 	return resp, nil
 }
+
+// revertLink 拼接撤销链接，未配置 revert_url 时返回空，邮件中改为直接给出撤销令牌
+func revertLink(token string) string {
+	base := config.Conf.Email.RevertURL
+	if base == "" {
+		return ""
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		global.Log.Errorf("revert_url 配置错误:%v", err)
+		return ""
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// newProfileResp 用户资料，不包含密码等敏感字段
+func newProfileResp(u user.User) dto.ProfileResp {
+	return dto.ProfileResp{
+		UserID:   u.UserID.String(),
+		Account:  u.Account,
+		Username: u.Username,
+		Email:    u.Email,
+		Avatar:   u.Avatar,
+		Role:     u.Role,
+		Locale:   u.Locale,
+		Ctime:    u.Ctime,
+		Utime:    u.Utime,
+	}
+}
+
+// userLocale 返回用户的偏好语言，未设置时返回请求的语言
+func userLocale(ctx context.Context, preferred string) string {
+	if locale, ok := i18nx.Normalize(preferred); ok {
+		return locale
+	}
+	return i18nx.FromContext(ctx)
+}
+
+// newCodeResp 只告知验证码已发送及有效期，不返回验证码本身（dev 回显模式除外）
+func newCodeResp(code, messageID string) dto.GetCodeResp {
+	resp := dto.GetCodeResp{
+		MessageID:  messageID,
+		ExpireIn:   int64(constant.CODE_TTL.Seconds()),
+		RetryAfter: int64(constant.CODE_RESEND_WAIT.Seconds()),
+	}
+	if config.Conf.App.DevEcho() {
+		resp.Code = code
+	}
+	return resp
+}
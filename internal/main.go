@@ -1,13 +1,33 @@
 package main
 
 import (
+	"context"
 	"nurture/internal/config"
 	"nurture/internal/global"
+	"nurture/internal/logic"
+	"nurture/internal/pkg/emailx"
+	"nurture/internal/pkg/lifecyclex"
 	"nurture/internal/router"
+	"os"
 )
 
 func main() {
 	config.LoadConfig() //加载配置
 	global.Init()       //初始化全局中间件
-	router.RunServer()  //启动服务端
+	mailer := emailx.InitMailer(config.Conf.Email)
+	worker := emailx.NewWorker(logic.NewOutbox(), mailer)
+	worker.Start() //启动邮件发件箱的投递
+	global.Lifecycle.Add("邮件投递", lifecyclex.Wait(worker.Stop))
+	purger := logic.NewAccountPurger()
+	purger.Start() //启动注销账号的清理
+	global.Lifecycle.Add("注销账号清理", lifecyclex.Wait(purger.Stop))
+	router.RunServer() //启动服务端，收到退出信号后返回
+
+	// 按注册的逆序停止：HTTP 服务、后台任务、Redis、数据库、链路追踪、日志
+	ctx, cancel := context.WithTimeout(context.Background(), config.Conf.App.ShutdownWait())
+	defer cancel()
+	if err := global.Lifecycle.Shutdown(ctx); err != nil {
+		cancel()
+		os.Exit(1)
+	}
 }
@@ -1,9 +1,12 @@
 package manager
 
 import (
+	"nurture/internal/config"
 	"nurture/internal/middleware"
+	"nurture/internal/pkg/otelx"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 //主要管理路由组和中间件的注册
@@ -18,13 +21,17 @@ type Middleware func() gin.HandlerFunc
 type RouteManager struct {
 	CommonRoutes *gin.RouterGroup //通用功能相关的路由组
 	UserRoutes   *gin.RouterGroup //用户相关的路由组
+	CodeRoutes   *gin.RouterGroup //验证码相关的路由组
+	AdminRoutes  *gin.RouterGroup //管理后台相关的路由组，只允许管理员访问
 }
 
 // NewRouteManager 创建一个新的 RouteManager 实例，包含各业务功能的路由组
 func NewRouteManager(router *gin.Engine) *RouteManager {
 	return &RouteManager{
-		CommonRoutes: router.Group("/api/common"), //通用功能相关的路由组
-		UserRoutes:   router.Group("/api/user"),   //用户相关的路由组
+		CommonRoutes: router.Group("/api/common"),    //通用功能相关的路由组
+		UserRoutes:   router.Group("/api/user"),      //用户相关的路由组
+		CodeRoutes:   router.Group("/api/user/code"), //验证码相关的路由组
+		AdminRoutes:  router.Group("/api/admin"),     //管理后台相关的路由组
 	}
 }
 
@@ -38,6 +45,16 @@ func (rm *RouteManager) RegisterUserRoutes(handler PathHandler) {
 	handler(rm.UserRoutes)
 }
 
+// RegisterCodeRoutes 验证码相关的路由组
+func (rm *RouteManager) RegisterCodeRoutes(handler PathHandler) {
+	handler(rm.CodeRoutes)
+}
+
+// RegisterAdminRoutes 管理后台相关的路由组
+func (rm *RouteManager) RegisterAdminRoutes(handler PathHandler) {
+	handler(rm.AdminRoutes)
+}
+
 // RegisterMiddleware 根据组名为对应的路由组注册中间件
 func (rm *RouteManager) RegisterMiddleware(group string, middleware Middleware) {
 	switch group {
@@ -45,10 +62,24 @@ func (rm *RouteManager) RegisterMiddleware(group string, middleware Middleware)
 		rm.CommonRoutes.Use(middleware())
 	case "user":
 		rm.UserRoutes.Use(middleware())
+	case "code":
+		rm.CodeRoutes.Use(middleware())
+	case "admin":
+		rm.AdminRoutes.Use(middleware())
 	}
 }
 
 // RequestGlobalMiddleware 注册全局中间件，应用于所有路由
 func RequestGlobalMiddleware(r *gin.Engine) {
+	if config.Conf.Telemetry.Enable {
+		// 为每个请求创建 span，并从 traceparent 请求头继续调用方的链路
+		r.Use(otelgin.Middleware(otelx.ServiceName(config.Conf.Telemetry)))
+	}
+	r.Use(middleware.RequestID())
+	if config.Conf.Metrics.Enable {
+		r.Use(middleware.Metrics())
+	}
+	r.Use(middleware.AccessLog())
 	r.Use(middleware.Cors())
+	r.Use(middleware.Locale())
 }
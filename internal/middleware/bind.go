@@ -1,17 +1,20 @@
 package middleware
 
 import (
+	"nurture/internal/pkg/errorx"
+	"nurture/internal/pkg/i18nx"
 	"nurture/internal/pkg/response"
+	"nurture/internal/pkg/validatex"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 func BindJsonMiddleware[T any](c *gin.Context) {
 	var cr T
 	err := c.ShouldBindJSON(&cr)
 	if err != nil {
-		response.Response(c, nil, err)
-		c.Abort()
+		abortBind(c, err)
 		return
 	}
 	c.Set("request", cr)
@@ -20,8 +23,7 @@ func BindQueryMiddleware[T any](c *gin.Context) {
 	var cr T
 	err := c.ShouldBindQuery(&cr)
 	if err != nil {
-		response.Response(c, nil, err)
-		c.Abort()
+		abortBind(c, err)
 		return
 	}
 	c.Set("request", cr)
@@ -30,8 +32,25 @@ func BindUriMiddleware[T any](c *gin.Context) {
 	var cr T
 	err := c.ShouldBindUri(&cr)
 	if err != nil {
-		response.Response(c, nil, err)
-		c.Abort()
+		abortBind(c, err)
+		return
+	}
+	c.Set("request", cr)
+}
+
+// BindUriJsonMiddleware 同时绑定路径参数和 JSON 请求体，路径参数先填充，校验在绑定请求体时统一进行
+func BindUriJsonMiddleware[T any](c *gin.Context) {
+	var cr T
+	params := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = []string{p.Value}
+	}
+	if err := binding.MapFormWithTag(&cr, params, "uri"); err != nil {
+		abortBind(c, err)
+		return
+	}
+	if err := c.ShouldBindJSON(&cr); err != nil {
+		abortBind(c, err)
 		return
 	}
 	c.Set("request", cr)
@@ -39,3 +58,12 @@ func BindUriMiddleware[T any](c *gin.Context) {
 func GetBind[T any](c *gin.Context) T {
 	return c.MustGet("request").(T)
 }
+
+// abortBind 校验失败时返回逐字段的错误信息，请求体格式错误时不返回细节，避免泄露内部结构
+func abortBind(c *gin.Context, err error) {
+	if fields := validatex.Translate(err, i18nx.FromContext(c.Request.Context())); fields != nil {
+		response.Abort(c, errorx.ErrParams.WithDetails(fields))
+		return
+	}
+	response.Abort(c, errorx.ErrParams)
+}
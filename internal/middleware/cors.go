@@ -11,8 +11,8 @@ import (
 func Cors() gin.HandlerFunc {
 	return cors.New(cors.Config{
 		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:  []string{"Authorization", "Content-Type"},
-		ExposeHeaders: []string{"Content-Length"},
+		AllowHeaders:  []string{"Authorization", "Content-Type", "X-Request-ID", "traceparent"},
+		ExposeHeaders: []string{"Content-Length", "Retry-After", "X-Request-ID"},
 		//是否允许你带cookie之类的东西
 		AllowCredentials: true,
 		AllowOriginFunc: func(origin string) bool {
@@ -2,36 +2,71 @@ package middleware
 
 import (
 	"nurture/internal/constant"
+	"nurture/internal/pkg/i18nx"
 	"nurture/internal/pkg/jwtx"
 	"nurture/internal/pkg/response"
+	"nurture/internal/repo"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func Authentication(role jwtx.Role) gin.HandlerFunc {
+	sessionRepo := repo.NewSessionRepo()
+	userRepo := repo.NewUserRepo()
 	return func(c *gin.Context) {
-		UserID, Role, err := jwtx.ParseToken(c)
+		claims, err := jwtx.ParseToken(c)
 		if err != nil {
-			c.JSON(401, response.Body{
-				Code:    -1,
-				Message: err.Error(),
-				Data:    nil,
-			})
-			c.Abort()
+			response.Abort(c, err)
 			return
 		}
-		if Role < role {
-			c.JSON(403, response.Body{
-				Code:    -1,
-				Message: jwtx.ErrPermissionDenied.Error(),
-				Data:    nil,
-			})
-			c.Abort()
+		if !checkSession(c, sessionRepo, claims) {
+			response.Abort(c, jwtx.ErrSessionRevoked)
 			return
 		}
-		//将用户id和角色加入ctx
-		c.Set(constant.TOKEN_USER_ID, UserID)
-		c.Set(constant.TOKEN_ROLE, Role)
+		if !checkUserState(c, userRepo, claims) {
+			response.Abort(c, jwtx.ErrTokenRevoked)
+			return
+		}
+		if claims.Role < role {
+			response.Abort(c, jwtx.ErrPermissionDenied)
+			return
+		}
+		//将用户id、角色和会话id加入ctx
+		c.Set(constant.TOKEN_USER_ID, claims.UserID)
+		c.Set(constant.TOKEN_ROLE, claims.Role)
+		c.Set(constant.TOKEN_SESSION_ID, claims.SessionID)
 		c.Next()
 	}
 }
+
+// checkSession 校验令牌所属的会话仍然有效，并按间隔更新会话的最近访问时间
+func checkSession(c *gin.Context, sessionRepo *repo.SessionRepo, claims *jwtx.MyClaims) bool {
+	ctx := c.Request.Context()
+	s, err := sessionRepo.GetSession(ctx, claims.SessionID)
+	if err != nil {
+		return false
+	}
+	now := time.Now()
+	if s.Revoked || s.UserID.String() != claims.UserID || now.UnixMilli() > s.ExpireTime {
+		return false
+	}
+	if now.Sub(time.UnixMilli(s.LastSeen)) > constant.SESSION_TOUCH_WAIT {
+		// 更新失败不影响本次请求
+		_ = sessionRepo.TouchSession(ctx, claims.SessionID, c.ClientIP())
+	}
+	return true
+}
+
+// checkUserState 校验令牌版本与用户当前的令牌版本一致，重置密码后旧令牌立即失效；
+// 用户设置了偏好语言时以偏好语言覆盖 Accept-Language 协商出的语言
+func checkUserState(c *gin.Context, userRepo *repo.UserRepo, claims *jwtx.MyClaims) bool {
+	state, err := userRepo.GetAuthState(c.Request.Context(), claims.UserID)
+	if err != nil || state.TokenVersion != claims.TokenVersion {
+		return false
+	}
+	if locale, ok := i18nx.Normalize(state.Locale); ok {
+		setLocale(c, locale)
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"nurture/internal/constant"
+	"nurture/internal/global"
+	"nurture/internal/pkg/errorx"
+	"nurture/internal/pkg/jwtx"
+	"nurture/internal/pkg/response"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CodeRateLimit 获取验证码的限流中间件：同一邮箱的同一用途有冷却时间，同一 IP 每小时有次数上限
+// IP 的次数只在请求最终放行时才计入，被冷却时间拒绝的重试不占用 IP 的次数
+func CodeRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		ipKey := fmt.Sprintf(constant.CODE_IP_KEY, c.ClientIP())
+		ok, wait, err := global.Limiter.Check(ctx, ipKey, constant.CODE_IP_LIMIT, constant.CODE_IP_WINDOW)
+		if err == nil && ok {
+			if email := peekEmail(c); email != "" {
+				ok, wait, err = global.Limiter.Allow(ctx, fmt.Sprintf(constant.CODE_EMAIL_KEY, codePurpose(c), email), 1, constant.CODE_RESEND_WAIT)
+			}
+		}
+		if err == nil && ok {
+			ok, wait, err = global.Limiter.Allow(ctx, ipKey, constant.CODE_IP_LIMIT, constant.CODE_IP_WINDOW)
+		}
+		limited(c, ok, wait, err)
+	}
+}
+
+// UserCodeRateLimit 已登录用户获取验证码的冷却时间，按用途和用户ID限流，需要放在 Authentication 之后
+// 注销账号等请求体中没有邮箱的接口只靠 IP 限流是不够的
+func UserCodeRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := fmt.Sprintf(constant.CODE_USER_KEY, codePurpose(c), jwtx.GetUserID(c))
+		ok, wait, err := global.Limiter.Allow(c.Request.Context(), key, 1, constant.CODE_RESEND_WAIT)
+		limited(c, ok, wait, err)
+	}
+}
+
+// codePurpose 验证码的用途，取路由的最后一段，例如 /api/code/login -> login
+func codePurpose(c *gin.Context) string {
+	return path.Base(c.FullPath())
+}
+
+// limited 根据限流结果放行或拒绝请求
+func limited(c *gin.Context, ok bool, wait time.Duration, err error) {
+	if err != nil {
+		// 限流组件故障时放行，避免影响正常业务
+		global.Logger(c.Request.Context()).Error(err)
+		c.Next()
+		return
+	}
+	if !ok {
+		abortRateLimited(c, wait)
+		return
+	}
+	c.Next()
+}
+
+// peekEmail 读取请求体中的邮箱，并把请求体放回去供后续的 Bind 中间件使用
+func peekEmail(c *gin.Context) string {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(req.Email))
+}
+
+func abortRateLimited(c *gin.Context, wait time.Duration) {
+	retryAfter := int64(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	response.Abort(c, errorx.ErrTooManyRequests.WithDetails(gin.H{"retry_after": retryAfter}))
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"nurture/internal/constant"
+	"nurture/internal/global"
+	"nurture/internal/pkg/limitx"
+	"nurture/internal/pkg/testx"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func newCodeRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	testx.Set(t, &global.Log, zap.NewNop().Sugar())
+	testx.Set[limitx.Limiter](t, &global.Limiter, limitx.NewMemoryLimiter())
+	r := gin.New()
+	rg := r.Group("/api/code", CodeRateLimit())
+	for _, purpose := range []string{"login", "register"} {
+		rg.POST("/"+purpose, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	return r
+}
+
+func requestCode(r *gin.Engine, purpose, email string) int {
+	w := httptest.NewRecorder()
+	body := fmt.Sprintf(`{"email":%q}`, email)
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/code/"+purpose, strings.NewReader(body)))
+	return w.Code
+}
+
+func TestCodeRateLimitCooldown(t *testing.T) {
+	r := newCodeRouter(t)
+	if code := requestCode(r, "login", "a@example.com"); code != http.StatusOK {
+		t.Fatalf("first request: status %d", code)
+	}
+	// 冷却时间内的重试被拒绝，邮箱大小写不影响
+	for i := 0; i < constant.CODE_IP_LIMIT; i++ {
+		if code := requestCode(r, "login", "A@example.com"); code != http.StatusTooManyRequests {
+			t.Fatalf("retry %d: status %d, want 429", i+1, code)
+		}
+	}
+	// 不同用途的冷却时间互不影响
+	if code := requestCode(r, "register", "a@example.com"); code != http.StatusOK {
+		t.Fatalf("register code: status %d", code)
+	}
+	// 被冷却时间拒绝的重试不占用 IP 的次数
+	if code := requestCode(r, "login", "b@example.com"); code != http.StatusOK {
+		t.Fatalf("another email: status %d", code)
+	}
+}
+
+func TestCodeRateLimitIP(t *testing.T) {
+	r := newCodeRouter(t)
+	for i := 0; i < constant.CODE_IP_LIMIT; i++ {
+		if code := requestCode(r, "login", fmt.Sprintf("u%d@example.com", i)); code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i+1, code)
+		}
+	}
+	if code := requestCode(r, "login", "other@example.com"); code != http.StatusTooManyRequests {
+		t.Fatalf("request over the IP limit: status %d, want 429", code)
+	}
+	// IP 超限时不记录邮箱的冷却时间
+	if ok, _, _ := global.Limiter.Check(t.Context(), fmt.Sprintf(constant.CODE_EMAIL_KEY, "login", "other@example.com"), 1, constant.CODE_RESEND_WAIT); !ok {
+		t.Fatal("email cooldown should not be recorded for a rejected request")
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"nurture/internal/pkg/i18nx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale 根据 Accept-Language 协商响应使用的语言，登录用户设置了偏好语言时由 Authentication 覆盖
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setLocale(c, i18nx.Negotiate(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// setLocale 把语言写入请求的 ctx，供 logic 层和响应翻译使用
+func setLocale(c *gin.Context, locale string) {
+	c.Request = c.Request.WithContext(i18nx.WithLocale(c.Request.Context(), locale))
+	c.Header("Content-Language", locale)
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"nurture/internal/pkg/metricx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 按路由模板记录请求数、状态码和耗时
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		done := metricx.HTTPStart()
+		c.Next()
+		done(c.Request.Method, c.FullPath(), c.Writer.Status())
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"nurture/internal/pkg/tracex"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestID 确定本次请求的请求ID并写入请求的 ctx 和 X-Request-ID 响应头
+// 优先使用调用方的 X-Request-ID，其次使用链路追踪的 trace-id 或 traceparent 中的 trace-id，都没有时生成新的请求ID
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(tracex.HeaderRequestID)
+		if !tracex.ValidRequestID(requestID) {
+			if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+				requestID = sc.TraceID().String()
+			} else if traceID, ok := tracex.ParseTraceparent(c.GetHeader(tracex.HeaderTraceparent)); ok {
+				requestID = traceID
+			} else {
+				requestID = tracex.NewRequestID()
+			}
+		}
+		c.Request = c.Request.WithContext(tracex.WithRequestID(c.Request.Context(), requestID))
+		c.Header(tracex.HeaderRequestID, requestID)
+		c.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package codex
+
+import (
+	"context"
+	"nurture/internal/config"
+	"nurture/internal/constant"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// CodeStore 验证码存储，内存和 Redis 两种实现行为保持一致
+type CodeStore interface {
+	// Store 写入验证码，ttl 后过期；重复写入同一个 key 会覆盖旧值、重新计时并清空错误次数
+	Store(ctx context.Context, key, code string, ttl time.Duration) error
+	// Verify 校验验证码，校验成功时原子地删除该 key，保证一个验证码只能使用一次
+	// 校验失败会累计错误次数，达到上限后验证码作废，防止在有效期内被暴力枚举
+	Verify(ctx context.Context, key, code string) (bool, error)
+}
+
+// InitCodeStore 根据配置选择验证码存储，开启 Redis 时使用 Redis，否则使用进程内存
+func InitCodeStore(rdb redis.Cmdable) CodeStore {
+	if config.Conf.Redis.Enable && rdb != nil {
+		return NewRedisCodeStore(rdb, constant.CODE_MAX_ATTEMPTS)
+	}
+	return NewMemoryCodeStore(constant.CODE_MAX_ATTEMPTS)
+}
@@ -0,0 +1,107 @@
+package codex
+
+import (
+	"context"
+	"nurture/internal/pkg/testx"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testMaxAttempts = 3
+	testTTL         = 50 * time.Millisecond
+)
+
+func TestMemoryCodeStore(t *testing.T) {
+	testCodeStore(t, NewMemoryCodeStore(testMaxAttempts), func(d time.Duration) {
+		time.Sleep(d)
+	})
+}
+
+func TestRedisCodeStore(t *testing.T) {
+	mr, rdb := testx.Redis(t)
+	testCodeStore(t, NewRedisCodeStore(rdb, testMaxAttempts), mr.FastForward)
+}
+
+// testCodeStore 两种实现需要通过同样的用例，wait 用于让时间流逝 d
+func testCodeStore(t *testing.T, s CodeStore, wait func(d time.Duration)) {
+	ctx := context.Background()
+	verify := func(t *testing.T, key, code string, want bool) {
+		t.Helper()
+		ok, err := s.Verify(ctx, key, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != want {
+			t.Fatalf("Verify(%q, %q) = %t, want %t", key, code, ok, want)
+		}
+	}
+	store := func(t *testing.T, key, code string) {
+		t.Helper()
+		if err := s.Store(ctx, key, code, testTTL); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("single use", func(t *testing.T) {
+		store(t, "once", "123456")
+		verify(t, "once", "123456", true)
+		verify(t, "once", "123456", false)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		verify(t, "unknown", "123456", false)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		store(t, "wrong", "123456")
+		verify(t, "wrong", "000000", false)
+		verify(t, "wrong", "123456", true)
+	})
+
+	t.Run("too many attempts", func(t *testing.T) {
+		store(t, "attempts", "123456")
+		for i := 0; i < testMaxAttempts; i++ {
+			verify(t, "attempts", "000000", false)
+		}
+		// 输错次数达到上限后验证码作废
+		verify(t, "attempts", "123456", false)
+	})
+
+	t.Run("store resets attempts", func(t *testing.T) {
+		store(t, "resend", "111111")
+		for i := 0; i < testMaxAttempts-1; i++ {
+			verify(t, "resend", "000000", false)
+		}
+		store(t, "resend", "222222")
+		verify(t, "resend", "111111", false)
+		verify(t, "resend", "222222", true)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		store(t, "expired", "123456")
+		wait(testTTL + 10*time.Millisecond)
+		verify(t, "expired", "123456", false)
+	})
+
+	t.Run("concurrent verify", func(t *testing.T) {
+		store(t, "concurrent", "123456")
+		var wg sync.WaitGroup
+		var success atomic.Int32
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if ok, err := s.Verify(ctx, "concurrent", "123456"); err == nil && ok {
+					success.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if n := success.Load(); n != 1 {
+			t.Fatalf("%d concurrent verifications succeeded, want 1", n)
+		}
+	})
+}
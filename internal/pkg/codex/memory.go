@@ -0,0 +1,51 @@
+package codex
+
+import (
+	"context"
+	"nurture/internal/pkg/syncx"
+	"sync/atomic"
+	"time"
+)
+
+type memoryEntry struct {
+	code     string
+	expireAt time.Time
+	attempts atomic.Int32 // 已输错的次数
+}
+
+// MemoryCodeStore 基于进程内存的验证码存储，重启后丢失，只适用于单实例部署
+type MemoryCodeStore struct {
+	m           syncx.Map[string, *memoryEntry]
+	maxAttempts int32
+}
+
+func NewMemoryCodeStore(maxAttempts int) *MemoryCodeStore {
+	return &MemoryCodeStore{maxAttempts: int32(maxAttempts)}
+}
+
+var _ CodeStore = (*MemoryCodeStore)(nil)
+
+func (s *MemoryCodeStore) Store(_ context.Context, key, code string, ttl time.Duration) error {
+	entry := &memoryEntry{code: code, expireAt: time.Now().Add(ttl)}
+	s.m.Store(key, entry)
+	time.AfterFunc(ttl, func() {
+		// 只删除本次写入的值，避免把之后重新发送的验证码误删
+		s.m.CompareAndDelete(key, entry)
+	})
+	return nil
+}
+
+func (s *MemoryCodeStore) Verify(_ context.Context, key, code string) (bool, error) {
+	entry, ok := s.m.Load(key)
+	if !ok || time.Now().After(entry.expireAt) {
+		return false, nil
+	}
+	if entry.code != code {
+		if entry.attempts.Add(1) >= s.maxAttempts {
+			s.m.CompareAndDelete(key, entry)
+		}
+		return false, nil
+	}
+	// 并发校验同一个验证码时只有一个能删除成功
+	return s.m.CompareAndDelete(key, entry), nil
+}
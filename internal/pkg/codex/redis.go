@@ -0,0 +1,62 @@
+package codex
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// verifyScript 比较并删除，保证校验和删除是原子的
+// KEYS[1] 为验证码，KEYS[2] 为错误次数；错误次数与验证码同时过期
+var verifyScript = redis.NewScript(`
+local v = redis.call("GET", KEYS[1])
+if not v then
+	return 0
+end
+if v == ARGV[1] then
+	redis.call("DEL", KEYS[1], KEYS[2])
+	return 1
+end
+local n = redis.call("INCR", KEYS[2])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[2], redis.call("PTTL", KEYS[1]))
+end
+if n >= tonumber(ARGV[2]) then
+	redis.call("DEL", KEYS[1], KEYS[2])
+end
+return 0
+`)
+
+// RedisCodeStore 基于 Redis 的验证码存储，支持多实例部署
+type RedisCodeStore struct {
+	rdb         redis.Cmdable
+	maxAttempts int
+}
+
+func NewRedisCodeStore(rdb redis.Cmdable, maxAttempts int) *RedisCodeStore {
+	return &RedisCodeStore{rdb: rdb, maxAttempts: maxAttempts}
+}
+
+var _ CodeStore = (*RedisCodeStore)(nil)
+
+func (s *RedisCodeStore) Store(ctx context.Context, key, code string, ttl time.Duration) error {
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, code, ttl)
+		pipe.Del(ctx, attemptsKey(key))
+		return nil
+	})
+	return err
+}
+
+func (s *RedisCodeStore) Verify(ctx context.Context, key, code string) (bool, error) {
+	n, err := verifyScript.Run(ctx, s.rdb, []string{key, attemptsKey(key)}, code, s.maxAttempts).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func attemptsKey(key string) string {
+	return key + ":attempts"
+}
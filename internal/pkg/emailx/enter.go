@@ -3,128 +3,135 @@ package emailx
 import (
 	"context"
 	"crypto/rand"
-	"errors"
 	"fmt"
-	"net/smtp"
 	"nurture/internal/config"
 	"nurture/internal/constant"
 	"nurture/internal/global"
-	"nurture/internal/pkg/syncx"
-	"strings"
+	"nurture/internal/pkg/codex"
+	"nurture/internal/pkg/i18nx"
+	"nurture/internal/pkg/metricx"
+	"nurture/internal/pkg/otelx"
+	"nurture/internal/pkg/tplx"
 	"time"
 
-	"github.com/jordan-wright/email"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var (
-	ErrSendOverTime = errors.New("邮件发送超时")
-)
+var tracer = otelx.Tracer("emailx")
 
 type EmailX struct {
-	config config.Email
-	ttl    time.Duration
-	store  *syncx.Map[string, string]
+	config    config.Email
+	ttl       time.Duration
+	store     codex.CodeStore
+	templates *tplx.Registry
+	outbox    Outbox
 }
 
-func NewEmailX() *EmailX {
+// NewEmailX 创建邮件服务，邮件写入 outbox 后由 Worker 异步投递
+func NewEmailX(outbox Outbox) *EmailX {
 	return &EmailX{
-		config: config.Conf.Email,
-		ttl:    10 * time.Minute,
-		store:  global.CodeStore,
+		config:    config.Conf.Email,
+		ttl:       constant.CODE_TTL,
+		store:     global.CodeStore,
+		templates: global.MailTemplates,
+		outbox:    outbox,
 	}
 }
 
-func (ex *EmailX) SendLoginCode(ctx context.Context, to string, code string) (err error) {
-	subject := fmt.Sprintf("[%s]邮箱登录", ex.config.Subject)
-	text := fmt.Sprintf("你正在进行邮箱登录，登录的验证码是：%s，十分钟内有效", code)
-	if err := ex.sendEmail(ctx, to, subject, text); err != nil {
-		return err
-	}
-	ex.store.StoreWithTTL(fmt.Sprintf(constant.LOGIN_CODE_KEY, to), code, ex.ttl)
-	return nil
+// SendLoginCode 发送登录验证码，邮件语言取 ctx 中的语言，返回的邮件ID可用于查询投递状态
+func (ex *EmailX) SendLoginCode(ctx context.Context, to string, code string) (messageID string, err error) {
+	return ex.sendCode(ctx, "", to, code, "login_code", fmt.Sprintf(constant.LOGIN_CODE_KEY, to))
 }
-func (ex *EmailX) SendResetPwdCode(ctx context.Context, to string, code string) (err error) {
-	subject := fmt.Sprintf("[%s]重置密码", ex.config.Subject)
-	text := fmt.Sprintf("你正在进行账号密码重置，重置的验证码是：%s，十分钟内有效", code)
-	if err := ex.sendEmail(ctx, to, subject, text); err != nil {
-		return err
-	}
-	ex.store.StoreWithTTL(fmt.Sprintf(constant.RESET_PWD_CODE_KEY, to), code, ex.ttl)
-	return nil
+
+func (ex *EmailX) SendResetPwdCode(ctx context.Context, to string, code string) (messageID string, err error) {
+	return ex.sendCode(ctx, "", to, code, "reset_code", fmt.Sprintf(constant.RESET_PWD_CODE_KEY, to))
 }
 
-func (ex *EmailX) SendRegisterCode(ctx context.Context, to string, code string) (err error) {
-	subject := fmt.Sprintf("[%s]注册账号", ex.config.Subject)
-	text := fmt.Sprintf("你正在进行账号注册，注册的验证码是：%s，十分钟内有效", code)
-	if err := ex.sendEmail(ctx, to, subject, text); err != nil {
-		return err
-	}
-	ex.store.StoreWithTTL(fmt.Sprintf(constant.REGISTER_CODE_KEY, to), code, ex.ttl)
-	return nil
+func (ex *EmailX) SendRegisterCode(ctx context.Context, to string, code string) (messageID string, err error) {
+	return ex.sendCode(ctx, "", to, code, "register_code", fmt.Sprintf(constant.REGISTER_CODE_KEY, to))
 }
 
-func (ex *EmailX) sendEmail(ctx context.Context, to, subject, text string) error {
-	e := email.NewEmail()
-	e.From = fmt.Sprintf("%s <%s>", ex.config.SendNickname, ex.config.SendEmail)
-	e.To = []string{to}
-	e.Subject = subject
-	e.Text = []byte(text)
-
-	addr := fmt.Sprintf("%s:%d", ex.config.Domain, ex.config.Port)
-	auth := smtp.PlainAuth("", ex.config.SendEmail, ex.config.AuthCode, ex.config.Domain)
-
-	type result struct{ err error }
-	done := make(chan result, 1)
-
-	// 1. 计算剩余时间
-	var timeout time.Duration
-	if dl, ok := ctx.Deadline(); ok {
-		timeout = time.Until(dl)
-		if timeout <= 0 {
-			return context.DeadlineExceeded
-		}
-	} else {
-		timeout = 10 * time.Second // 调用方没给 deadline 就用默认,10s 是为了防止邮件发送失败
-	}
+// SendChangeEmailCode 向新邮箱发送修改邮箱的验证码，验证码与发起修改的用户绑定
+func (ex *EmailX) SendChangeEmailCode(ctx context.Context, userID, to, code string) (messageID string, err error) {
+	return ex.sendCode(ctx, userID, to, code, "change_email_code", fmt.Sprintf(constant.CHANGE_EMAIL_CODE_KEY, userID, to))
+}
 
-	// 2. 异步发送
-	go func() {
-		err := e.Send(addr, auth)
-		// 过滤掉某些老旧服务器返回的“short response”伪错误
-		if err != nil && !strings.Contains(err.Error(), "short response") {
-			done <- result{err: err}
-			return
-		}
-		done <- result{err: nil}
-	}()
-
-	// 3. 等待完成或超时 / 被取消
-	select {
-	case res := <-done:
-		return res.err
-	case <-time.After(timeout):
-		return ErrSendOverTime
-	case <-ctx.Done():
-		return ctx.Err()
-	}
+// SendDeleteAccountCode 向账号邮箱发送注销账号的验证码
+func (ex *EmailX) SendDeleteAccountCode(ctx context.Context, userID, to, code string) (messageID string, err error) {
+	return ex.sendCode(ctx, userID, to, code, "delete_account_code", fmt.Sprintf(constant.DELETE_ACCOUNT_CODE_KEY, userID))
 }
 
-func (ex *EmailX) VerifyCode(key, code string) bool {
-	var ans bool
-	if v, ok := ex.store.Load(key); ok {
-		if v == code {
-			ans = true
-			ex.store.Delete(key)
-		}
+// sendCode 使用 name 模板发送验证码邮件，邮件入队成功后保存验证码
+// 验证码过期后邮件就没有意义了，过期仍未投递的邮件不再投递
+func (ex *EmailX) sendCode(ctx context.Context, userID, to, code, name, storeKey string) (messageID string, err error) {
+	defer func() { metricx.CodeSent(storeKey, err) }()
+	messageID, err = ex.send(ctx, userID, to, name, map[string]any{
+		"Code":    code,
+		"Minutes": int(ex.ttl.Minutes()),
+	}, time.Now().Add(ex.ttl))
+	if err != nil {
+		return "", err
 	}
-	return ans
+	return messageID, ex.store.Store(ctx, storeKey, code, ex.ttl)
+}
+
+// Send 使用 name 模板渲染邮件并写入发件箱，邮件语言取 ctx 中的语言
+// 模板中除 data 外还可以使用 .App（邮件主题前缀）和 .Locale
+func (ex *EmailX) Send(ctx context.Context, to, name string, data map[string]any) (messageID string, err error) {
+	return ex.send(ctx, "", to, name, data, time.Time{})
+}
+
+// SendToUser 与 Send 相同，邮件归属于 userID，用户可以查询投递状态
+func (ex *EmailX) SendToUser(ctx context.Context, userID, to, name string, data map[string]any) (messageID string, err error) {
+	return ex.send(ctx, userID, to, name, data, time.Time{})
 }
 
-func (ex *EmailX) ShowDataForDebug() {
-	ex.store.Range(func(key, value string) bool {
-		global.Log.Debugf("key:%s, value:%s", key, value)
-		return true
+// send 渲染邮件并写入发件箱，expireAt 为零值表示不过期
+func (ex *EmailX) send(ctx context.Context, userID, to, name string, data map[string]any, expireAt time.Time) (messageID string, err error) {
+	locale := i18nx.FromContext(ctx)
+	ctx, span := tracer.Start(ctx, "emailx.Send", trace.WithAttributes(
+		attribute.String("email.template", name),
+		attribute.String("email.locale", locale),
+	))
+	defer func() { otelx.EndSpan(span, err) }()
+	params := make(map[string]any, len(data)+2)
+	for k, v := range data {
+		params[k] = v
+	}
+	params["App"] = ex.config.Subject
+	params["Locale"] = locale
+	msg, err := ex.templates.Render(locale, name, params)
+	if err != nil {
+		return "", err
+	}
+	messageID = uuid.NewString()
+	span.SetAttributes(attribute.String("email.message_id", messageID))
+	err = ex.outbox.Enqueue(ctx, OutboxEmail{
+		MessageID: messageID,
+		UserID:    userID,
+		To:        to,
+		Template:  name,
+		Locale:    locale,
+		Message:   msg,
+		ExpireAt:  expireAt,
 	})
+	if err != nil {
+		return "", err
+	}
+	return messageID, nil
+}
+
+// VerifyCode 校验验证码，校验成功后验证码立即失效
+func (ex *EmailX) VerifyCode(ctx context.Context, key, code string) bool {
+	ok, err := ex.store.Verify(ctx, key, code)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return false
+	}
+	metricx.CodeVerified(key, ok)
+	return ok
 }
 
 func GenCode() string {
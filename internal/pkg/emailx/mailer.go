@@ -0,0 +1,243 @@
+package emailx
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"nurture/internal/config"
+	"nurture/internal/pkg/tplx"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jordan-wright/email"
+)
+
+// 邮件驱动，由 email.driver 配置
+const (
+	DriverSMTP   = "smtp"   // 通过 SMTP 服务器发送，按 ssl / tls / plain 选择连接方式
+	DriverFile   = "file"   // 以 Maildir 格式写入本地目录，用于开发环境查看邮件
+	DriverMemory = "memory" // 只记录在内存中，用于测试
+)
+
+// SMTP 连接方式
+const (
+	SecuritySSL      = "ssl"      // 隐式 TLS，通常为 465 端口
+	SecurityStartTLS = "starttls" // 先明文连接再通过 STARTTLS 升级，通常为 587 端口，服务器不支持时报错
+	SecurityAuto     = "auto"     // 服务器支持 STARTTLS 时升级，否则使用明文，与 smtp.SendMail 的行为一致
+	SecurityPlain    = "plain"    // 不加密，只应在本地或内网的 SMTP 中继上使用
+)
+
+var ErrStartTLSUnsupported = errors.New("smtp server does not support STARTTLS")
+
+// Mail 一封待投递的邮件
+type Mail struct {
+	To      string
+	Message tplx.Message
+}
+
+// Mailer 邮件投递的传输层
+type Mailer interface {
+	Send(ctx context.Context, m Mail) error
+}
+
+// InitMailer 按配置创建邮件驱动，配置错误时直接 panic
+func InitMailer(conf config.Email) Mailer {
+	m, err := NewMailer(conf)
+	if err != nil {
+		panic(fmt.Sprintf("init mailer error: %v", err))
+	}
+	return m
+}
+
+func NewMailer(conf config.Email) (Mailer, error) {
+	from := (&mail.Address{Name: conf.SendNickname, Address: conf.SendEmail}).String()
+	switch conf.Driver {
+	case "", DriverSMTP:
+		security := SecurityAuto
+		switch {
+		case conf.SSL && (conf.TLS || conf.Plain), conf.TLS && conf.Plain:
+			return nil, errors.New("only one of email.ssl, email.tls and email.plain can be enabled")
+		case conf.SSL:
+			security = SecuritySSL
+		case conf.TLS:
+			security = SecurityStartTLS
+		case conf.Plain:
+			security = SecurityPlain
+		}
+		return &SMTPMailer{
+			host:     conf.Domain,
+			port:     conf.Port,
+			username: conf.SendEmail,
+			password: conf.AuthCode,
+			from:     from,
+			security: security,
+		}, nil
+	case DriverFile:
+		return NewFileMailer(conf.Maildir, from)
+	case DriverMemory:
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported email driver %q", conf.Driver)
+	}
+}
+
+// compose 生成包含 HTML 和纯文本两个版本的 MIME 邮件
+func compose(from string, m Mail) ([]byte, error) {
+	e := email.NewEmail()
+	e.From = from
+	e.To = []string{m.To}
+	e.Subject = m.Message.Subject
+	e.HTML = []byte(m.Message.HTML)
+	if m.Message.Text != "" {
+		e.Text = []byte(m.Message.Text)
+	}
+	return e.Bytes()
+}
+
+// SMTPMailer 通过 SMTP 服务器投递邮件，连接的读写受 ctx 的截止时间约束
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	security string
+}
+
+func (sm *SMTPMailer) Send(ctx context.Context, m Mail) error {
+	raw, err := compose(sm.from, m)
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(sm.host, strconv.Itoa(sm.port)))
+	if err != nil {
+		return err
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+	// ctx 取消时关闭连接，使阻塞中的读写立即返回
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	if sm.security == SecuritySSL {
+		conn = tls.Client(conn, &tls.Config{ServerName: sm.host})
+	}
+	c, err := smtp.NewClient(conn, sm.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if sm.security == SecurityStartTLS || sm.security == SecurityAuto {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: sm.host}); err != nil {
+				return err
+			}
+		} else if sm.security == SecurityStartTLS {
+			return ErrStartTLSUnsupported
+		}
+	}
+	if sm.password != "" {
+		// PlainAuth 拒绝在非本地的明文连接上发送密码
+		if err := c.Auth(smtp.PlainAuth("", sm.username, sm.password, sm.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(sm.username); err != nil {
+		return err
+	}
+	if err := c.Rcpt(m.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	// DATA 结束时服务器返回 250 即表示已经接收邮件
+	if err := w.Close(); err != nil {
+		return err
+	}
+	// 部分服务器收到 QUIT 后不回复直接断开连接，此时邮件已经投递成功，忽略 QUIT 的错误
+	_ = c.Quit()
+	return nil
+}
+
+// FileMailer 以 Maildir 格式把邮件写入本地目录：先写入 tmp 再原子地移动到 new
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if dir == "" {
+		return nil, errors.New("email.maildir is required by the file driver")
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (fm *FileMailer) Send(ctx context.Context, m Mail) error {
+	raw, err := compose(fm.from, m)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.%s.nurture", time.Now().UnixNano(), uuid.NewString())
+	tmp := filepath.Join(fm.dir, "tmp", name)
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(fm.dir, "new", name))
+}
+
+// MemoryMailer 把邮件记录在内存中，用于测试时断言发出的邮件
+type MemoryMailer struct {
+	mu    sync.Mutex
+	mails []Mail
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (mm *MemoryMailer) Send(ctx context.Context, m Mail) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.mails = append(mm.mails, m)
+	return nil
+}
+
+// Mails 返回已记录的邮件的副本
+func (mm *MemoryMailer) Mails() []Mail {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return append([]Mail(nil), mm.mails...)
+}
+
+// Reset 清空已记录的邮件
+func (mm *MemoryMailer) Reset() {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.mails = nil
+}
+
+var (
+	_ Mailer = (*SMTPMailer)(nil)
+	_ Mailer = (*FileMailer)(nil)
+	_ Mailer = (*MemoryMailer)(nil)
+)
@@ -0,0 +1,267 @@
+package emailx
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"nurture/internal/config"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewMailer(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		conf     config.Email
+		security string // 仅 SMTP 驱动检查
+		wantErr  bool
+	}{
+		{name: "default smtp", conf: config.Email{}, security: SecurityAuto},
+		{name: "ssl", conf: config.Email{Driver: DriverSMTP, SSL: true}, security: SecuritySSL},
+		{name: "starttls", conf: config.Email{Driver: DriverSMTP, TLS: true}, security: SecurityStartTLS},
+		{name: "plain", conf: config.Email{Driver: DriverSMTP, Plain: true}, security: SecurityPlain},
+		{name: "ssl and tls", conf: config.Email{SSL: true, TLS: true}, wantErr: true},
+		{name: "tls and plain", conf: config.Email{TLS: true, Plain: true}, wantErr: true},
+		{name: "file", conf: config.Email{Driver: DriverFile, Maildir: dir}},
+		{name: "file without maildir", conf: config.Email{Driver: DriverFile}, wantErr: true},
+		{name: "memory", conf: config.Email{Driver: DriverMemory}},
+		{name: "unknown", conf: config.Email{Driver: "sendmail"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMailer(tt.conf)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %T", m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch tt.conf.Driver {
+			case "", DriverSMTP:
+				sm, ok := m.(*SMTPMailer)
+				if !ok {
+					t.Fatalf("got %T, want *SMTPMailer", m)
+				}
+				if sm.security != tt.security {
+					t.Fatalf("security = %q, want %q", sm.security, tt.security)
+				}
+			case DriverFile:
+				if _, ok := m.(*FileMailer); !ok {
+					t.Fatalf("got %T, want *FileMailer", m)
+				}
+			case DriverMemory:
+				if _, ok := m.(*MemoryMailer); !ok {
+					t.Fatalf("got %T, want *MemoryMailer", m)
+				}
+			}
+		})
+	}
+}
+
+// smtpStub 只支持发送邮件所需的最基本命令的 SMTP 服务，记录收到的命令
+// STARTTLS 总是返回 454，用于检查客户端是否尝试升级
+type smtpStub struct {
+	startTLS bool // EHLO 中是否声明支持 STARTTLS
+	mu       sync.Mutex
+	cmds     []string
+}
+
+func newSMTPStub(t *testing.T, startTLS bool) (*smtpStub, config.Email) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpStub{startTLS: startTLS}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return s, config.Email{Domain: addr.IP.String(), Port: addr.Port, SendEmail: "noreply@example.com"}
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 localhost ESMTP")
+	data := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if data {
+			if line == "." {
+				data = false
+				reply("250 OK")
+			}
+			continue
+		}
+		cmd := strings.ToUpper(strings.Fields(line + " ")[0])
+		s.mu.Lock()
+		s.cmds = append(s.cmds, cmd)
+		s.mu.Unlock()
+		switch cmd {
+		case "EHLO":
+			if s.startTLS {
+				reply("250-localhost")
+				reply("250 STARTTLS")
+			} else {
+				reply("250 localhost")
+			}
+		case "STARTTLS":
+			reply("454 TLS not available")
+		case "DATA":
+			data = true
+			reply("354 go ahead")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpStub) received(cmd string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Contains(s.cmds, cmd)
+}
+
+func TestSMTPMailerSecurity(t *testing.T) {
+	tests := []struct {
+		name     string
+		startTLS bool // 服务器是否支持 STARTTLS
+		tls      bool
+		plain    bool
+		upgrade  bool // 是否应该尝试 STARTTLS
+		wantErr  error
+	}{
+		// 未开启任何选项时，服务器支持 STARTTLS 就必须升级，不能以明文发送
+		{name: "auto upgrades", startTLS: true, upgrade: true},
+		{name: "auto falls back to plaintext", startTLS: false},
+		{name: "starttls required", startTLS: false, tls: true, wantErr: ErrStartTLSUnsupported},
+		{name: "explicit plain", startTLS: true, plain: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, conf := newSMTPStub(t, tt.startTLS)
+			conf.TLS, conf.Plain = tt.tls, tt.plain
+			m, err := NewMailer(conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = m.Send(context.Background(), testMail())
+			if got := stub.received("STARTTLS"); got != tt.upgrade {
+				t.Fatalf("STARTTLS attempted = %t, want %t", got, tt.upgrade)
+			}
+			switch {
+			case tt.upgrade:
+				// 升级失败时不能继续以明文投递
+				if err == nil || stub.received("MAIL") {
+					t.Fatalf("err = %v, mail should not be sent without TLS", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !stub.received("DATA") {
+					t.Fatal("mail should be delivered")
+				}
+			}
+		})
+	}
+}
+
+func TestCompose(t *testing.T) {
+	raw, err := compose(`"Nurture" <noreply@example.com>`, testMail())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(raw)
+	for _, want := range []string{
+		"From: \"Nurture\" <noreply@example.com>",
+		"To: <user@example.com>",
+		"multipart/alternative",
+		"text/plain",
+		"text/html",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("message should contain %q:\n%s", want, s)
+		}
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	fm, err := NewFileMailer(dir, "noreply@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := fm.Send(context.Background(), testMail()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("new/ has %d mails, want 2", len(entries))
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "new", entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "To: <user@example.com>") {
+		t.Fatalf("unexpected mail:\n%s", raw)
+	}
+	// 投递完成后 tmp 中不留下文件
+	if tmp, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(tmp) != 0 {
+		t.Fatalf("tmp/ has %d files, want none", len(tmp))
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mm := NewMemoryMailer()
+	if err := mm.Send(context.Background(), testMail()); err != nil {
+		t.Fatal(err)
+	}
+	mails := mm.Mails()
+	if len(mails) != 1 || mails[0].To != "user@example.com" {
+		t.Fatalf("mails = %+v", mails)
+	}
+	// 返回的是副本
+	mails[0].To = "other@example.com"
+	if mm.Mails()[0].To != "user@example.com" {
+		t.Fatal("Mails should return a copy")
+	}
+	mm.Reset()
+	if n := len(mm.Mails()); n != 0 {
+		t.Fatalf("got %d mails after Reset, want 0", n)
+	}
+}
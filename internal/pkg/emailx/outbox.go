@@ -0,0 +1,185 @@
+package emailx
+
+import (
+	"context"
+	"nurture/internal/config"
+	"nurture/internal/constant"
+	"nurture/internal/global"
+	"nurture/internal/pkg/metricx"
+	"nurture/internal/pkg/otelx"
+	"nurture/internal/pkg/tplx"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Outbox 邮件发件箱的持久化存储，返回的错误已经记录过日志
+type Outbox interface {
+	Enqueue(ctx context.Context, email OutboxEmail) error
+	// Claim 领取最多 batchSize 封到期的邮件并加上租约，租约到期前不会被再次领取
+	Claim(ctx context.Context, batchSize int32, lease time.Duration) ([]OutboxEmail, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkRetry(ctx context.Context, id int64, next time.Time, reason string) error
+	MarkDead(ctx context.Context, id int64, reason string) error
+	// Release 把已领取但还没开始投递的邮件放回发件箱，撤销 Claim 增加的投递次数
+	Release(ctx context.Context, id int64) error
+}
+
+// OutboxEmail 发件箱中的一封邮件，入队时已经渲染好
+type OutboxEmail struct {
+	ID        int64
+	MessageID string
+	UserID    string // 发起发送的用户，未登录时为空
+	To        string
+	Template  string
+	Locale    string
+	Message   tplx.Message
+	Attempts  int32     // 包括本次在内已经尝试投递的次数
+	ExpireAt  time.Time // 过期仍未投递的邮件直接进入死信，零值表示不过期
+}
+
+// Worker 从发件箱领取邮件并发投递，失败按指数退避重试，超过次数后进入死信
+type Worker struct {
+	outbox Outbox
+	mailer Mailer
+	jobs   chan OutboxEmail
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewWorker(outbox Outbox, mailer Mailer) *Worker {
+	return &Worker{
+		outbox: outbox,
+		mailer: mailer,
+		jobs:   make(chan OutboxEmail),
+	}
+}
+
+// Start 启动轮询和投递的 goroutine
+func (w *Worker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.wg.Add(1 + constant.OUTBOX_WORKERS)
+	go w.poll(ctx)
+	for i := 0; i < constant.OUTBOX_WORKERS; i++ {
+		go w.work()
+	}
+}
+
+// Stop 停止领取新邮件，并等待正在投递的邮件完成
+func (w *Worker) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// poll 领取到期的邮件交给 worker，领满一批时立即继续领取，否则等待下一次轮询
+func (w *Worker) poll(ctx context.Context) {
+	defer w.wg.Done()
+	defer close(w.jobs)
+	for {
+		// 领取失败时错误已经记录过，等待下一次轮询
+		emails, _ := w.outbox.Claim(ctx, constant.OUTBOX_WORKERS, constant.OUTBOX_LEASE)
+		for i, email := range emails {
+			select {
+			case w.jobs <- email:
+			case <-ctx.Done():
+				// 已领取但还没开始投递的邮件放回发件箱，不必等租约到期
+				w.release(emails[i:])
+				return
+			}
+		}
+		if len(emails) == constant.OUTBOX_WORKERS {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(constant.OUTBOX_POLL_WAIT):
+		}
+	}
+}
+
+func (w *Worker) work() {
+	defer w.wg.Done()
+	for email := range w.jobs {
+		w.deliver(email)
+	}
+}
+
+// deliver 投递一封邮件并记录结果，投递不受 Stop 影响，保证已开始的投递能完成
+// 投递在后台进行，span 是新链路的根节点，可以通过 email.message_id 与入队时的请求关联
+func (w *Worker) deliver(email OutboxEmail) {
+	if !email.ExpireAt.IsZero() && time.Now().After(email.ExpireAt) {
+		w.expire(email)
+		return
+	}
+	ctx, span := tracer.Start(context.Background(), "emailx.deliver", trace.WithAttributes(
+		attribute.String("email.message_id", email.MessageID),
+		attribute.String("email.template", email.Template),
+		attribute.Int("email.attempts", int(email.Attempts)),
+	))
+	start := time.Now()
+	err := w.send(ctx, email)
+	metricx.EmailSent(email.Template, time.Since(start), err)
+	// span 的状态只反映本次投递的结果
+	defer otelx.EndSpan(span, err)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err == nil {
+		err = w.outbox.MarkSent(ctx, email.ID)
+	} else if email.Attempts >= constant.OUTBOX_MAX_ATTEMPTS {
+		global.Log.Warnf("邮件%s投递失败%d次，进入死信:%v", email.MessageID, email.Attempts, err)
+		err = w.outbox.MarkDead(ctx, email.ID, err.Error())
+	} else {
+		global.Log.Warnf("邮件%s第%d次投递失败:%v", email.MessageID, email.Attempts, err)
+		err = w.outbox.MarkRetry(ctx, email.ID, time.Now().Add(retryDelay(email.Attempts)), err.Error())
+	}
+	if err != nil {
+		// 状态没有写回时，租约到期后会重新投递
+		global.Log.Warnf("邮件%s投递状态写回失败", email.MessageID)
+	}
+}
+
+// expire 过期的邮件不再投递，直接进入死信并清空正文
+func (w *Worker) expire(email OutboxEmail) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	global.Log.Warnf("邮件%s已过期，不再投递", email.MessageID)
+	if err := w.outbox.MarkDead(ctx, email.ID, "expired"); err != nil {
+		global.Log.Warnf("邮件%s投递状态写回失败", email.MessageID)
+	}
+}
+
+func (w *Worker) send(ctx context.Context, email OutboxEmail) error {
+	if config.Conf.App.DevEcho() {
+		// 回显模式下不投递，验证码由接口直接返回
+		global.Log.Debugf("dev echo 模式，跳过发送邮件 to:%s subject:%s", email.To, email.Message.Subject)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, constant.OUTBOX_SEND_TIMEOUT)
+	defer cancel()
+	return w.mailer.Send(ctx, Mail{To: email.To, Message: email.Message})
+}
+
+// release 把邮件放回发件箱立即重新投递，不占用投递次数
+func (w *Worker) release(emails []OutboxEmail) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, email := range emails {
+		if err := w.outbox.Release(ctx, email.ID); err != nil {
+			global.Log.Warnf("邮件%s放回发件箱失败", email.MessageID)
+		}
+	}
+}
+
+// retryDelay 第 attempts 次投递失败后的等待时间：base * 2^(attempts-1)，不超过上限
+func retryDelay(attempts int32) time.Duration {
+	d := constant.OUTBOX_RETRY_BASE
+	for i := int32(1); i < attempts && d < constant.OUTBOX_RETRY_MAX; i++ {
+		d *= 2
+	}
+	return min(d, constant.OUTBOX_RETRY_MAX)
+}
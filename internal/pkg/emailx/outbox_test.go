@@ -0,0 +1,236 @@
+package emailx
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/constant"
+	"nurture/internal/global"
+	"nurture/internal/pkg/tplx"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	global.Log = zap.NewNop().Sugar()
+	os.Exit(m.Run())
+}
+
+func TestRetryDelay(t *testing.T) {
+	base, limit := constant.OUTBOX_RETRY_BASE, constant.OUTBOX_RETRY_MAX
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{attempts: 0, want: base},
+		{attempts: 1, want: base},
+		{attempts: 2, want: 2 * base},
+		{attempts: 3, want: 4 * base},
+		{attempts: 4, want: 8 * base},
+		{attempts: 30, want: limit},
+		{attempts: 1000, want: limit},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+	// 等待时间单调不减且不超过上限
+	prev := time.Duration(0)
+	for attempts := int32(1); attempts <= constant.OUTBOX_MAX_ATTEMPTS*2; attempts++ {
+		d := retryDelay(attempts)
+		if d < prev || d > limit {
+			t.Fatalf("retryDelay(%d) = %s after %s", attempts, d, prev)
+		}
+		prev = d
+	}
+}
+
+// fakeOutbox 记录 Worker 写回的投递结果
+type fakeOutbox struct {
+	mu       sync.Mutex
+	sent     []int64
+	retry    map[int64]time.Time
+	dead     map[int64]string
+	released []int64
+	claims   [][]OutboxEmail
+}
+
+func newFakeOutbox(claims ...[]OutboxEmail) *fakeOutbox {
+	return &fakeOutbox{retry: map[int64]time.Time{}, dead: map[int64]string{}, claims: claims}
+}
+
+func (f *fakeOutbox) Enqueue(ctx context.Context, email OutboxEmail) error {
+	return nil
+}
+
+func (f *fakeOutbox) Claim(ctx context.Context, batchSize int32, lease time.Duration) ([]OutboxEmail, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.claims) == 0 {
+		return nil, nil
+	}
+	emails := f.claims[0]
+	f.claims = f.claims[1:]
+	return emails, nil
+}
+
+func (f *fakeOutbox) MarkSent(ctx context.Context, id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, id)
+	return nil
+}
+
+func (f *fakeOutbox) MarkRetry(ctx context.Context, id int64, next time.Time, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.retry[id] = next
+	return nil
+}
+
+func (f *fakeOutbox) MarkDead(ctx context.Context, id int64, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dead[id] = reason
+	return nil
+}
+
+func (f *fakeOutbox) Release(ctx context.Context, id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.released = append(f.released, id)
+	return nil
+}
+
+// failMailer 总是投递失败
+type failMailer struct{}
+
+func (failMailer) Send(ctx context.Context, m Mail) error {
+	return errors.New("smtp: connection refused")
+}
+
+func testMail() Mail {
+	return Mail{
+		To: "user@example.com",
+		Message: tplx.Message{
+			Subject: "登录验证码",
+			HTML:    "<p>123456</p>",
+			Text:    "123456",
+		},
+	}
+}
+
+func testEmail(id int64, attempts int32) OutboxEmail {
+	m := testMail()
+	return OutboxEmail{
+		ID:        id,
+		MessageID: "message",
+		To:        m.To,
+		Template:  "login_code",
+		Message:   m.Message,
+		Attempts:  attempts,
+	}
+}
+
+func TestDeliverSent(t *testing.T) {
+	outbox, mailer := newFakeOutbox(), NewMemoryMailer()
+	w := NewWorker(outbox, mailer)
+	w.deliver(testEmail(1, 1))
+	if len(outbox.sent) != 1 || outbox.sent[0] != 1 {
+		t.Fatalf("sent = %v, want [1]", outbox.sent)
+	}
+	if mails := mailer.Mails(); len(mails) != 1 || mails[0].To != "user@example.com" {
+		t.Fatalf("mails = %+v", mails)
+	}
+}
+
+func TestDeliverRetry(t *testing.T) {
+	outbox := newFakeOutbox()
+	w := NewWorker(outbox, failMailer{})
+	start := time.Now()
+	w.deliver(testEmail(1, 3))
+	next, ok := outbox.retry[1]
+	if !ok {
+		t.Fatalf("email should be scheduled for retry, dead=%v", outbox.dead)
+	}
+	want := start.Add(retryDelay(3))
+	if next.Before(want) || next.After(want.Add(time.Second)) {
+		t.Fatalf("next attempt at %s, want about %s", next, want)
+	}
+}
+
+func TestDeliverDead(t *testing.T) {
+	outbox := newFakeOutbox()
+	w := NewWorker(outbox, failMailer{})
+	w.deliver(testEmail(1, constant.OUTBOX_MAX_ATTEMPTS))
+	if reason := outbox.dead[1]; reason != "smtp: connection refused" {
+		t.Fatalf("dead reason = %q", reason)
+	}
+	if len(outbox.retry) != 0 {
+		t.Fatalf("retry = %v, want none", outbox.retry)
+	}
+}
+
+func TestDeliverExpired(t *testing.T) {
+	outbox, mailer := newFakeOutbox(), NewMemoryMailer()
+	w := NewWorker(outbox, mailer)
+	email := testEmail(1, 1)
+	email.ExpireAt = time.Now().Add(-time.Second)
+	w.deliver(email)
+	if reason := outbox.dead[1]; reason != "expired" {
+		t.Fatalf("dead reason = %q, want expired", reason)
+	}
+	if mails := mailer.Mails(); len(mails) != 0 {
+		t.Fatalf("expired email should not be sent, got %+v", mails)
+	}
+}
+
+func TestWorker(t *testing.T) {
+	emails := make([]OutboxEmail, constant.OUTBOX_WORKERS)
+	for i := range emails {
+		emails[i] = testEmail(int64(i+1), 1)
+	}
+	// 领满一批后立即继续领取
+	outbox, mailer := newFakeOutbox(emails, []OutboxEmail{testEmail(100, 1)}), NewMemoryMailer()
+	w := NewWorker(outbox, mailer)
+	w.Start()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		outbox.mu.Lock()
+		n := len(outbox.sent)
+		outbox.mu.Unlock()
+		if n == len(emails)+1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("sent %d emails, want %d", n, len(emails)+1)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	w.Stop()
+	if got := len(mailer.Mails()); got != len(emails)+1 {
+		t.Fatalf("mailer got %d emails, want %d", got, len(emails)+1)
+	}
+}
+
+func TestPollReleasesOnStop(t *testing.T) {
+	outbox := newFakeOutbox([]OutboxEmail{testEmail(1, 1), testEmail(2, 3)})
+	w := NewWorker(outbox, NewMemoryMailer())
+	// 没有 worker 接收时，已领取的邮件在停止后全部放回发件箱
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w.wg.Add(1)
+	w.poll(ctx)
+	if !reflect.DeepEqual(outbox.released, []int64{1, 2}) {
+		t.Fatalf("released = %v, want [1 2]", outbox.released)
+	}
+	// 放回不算一次失败的投递
+	if len(outbox.retry) != 0 || len(outbox.dead) != 0 {
+		t.Fatalf("retry = %v, dead = %v, want none", outbox.retry, outbox.dead)
+	}
+}
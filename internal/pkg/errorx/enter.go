@@ -0,0 +1,82 @@
+package errorx
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Error 带业务码的应用错误
+// Code 是稳定的业务码，前端据此判断错误类型；Status 是返回的 HTTP 状态码；
+// Key 是消息的国际化 key；Message 是默认的提示信息；Params 是消息中占位符的取值；Details 是可选的附加信息
+//
+// 业务码按模块分段：
+//
+//	1xxxx 通用错误
+//	2xxxx 令牌与会话
+//	3xxxx 用户
+//	4xxxx 文件
+//	5xxxx 邮件
+type Error struct {
+	Code    int
+	Status  int
+	Key     string
+	Message string
+	Params  map[string]any
+	Details any
+}
+
+var (
+	mu    sync.Mutex
+	codes = map[int]string{} // 已经使用的业务码和对应的 Key
+)
+
+// New 创建错误，业务码必须唯一，重复时 panic，使冲突在启动时就能发现
+// 不同层需要同一个错误时，应当复用已有的变量而不是重新创建
+func New(code, status int, key, message string) *Error {
+	mu.Lock()
+	defer mu.Unlock()
+	if used, ok := codes[code]; ok {
+		panic(fmt.Sprintf("errorx: 业务码 %d 重复，%s 与 %s", code, used, key))
+	}
+	codes[code] = key
+	return &Error{
+		Code:    code,
+		Status:  status,
+		Key:     key,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is 按 Key 判断是否为同一种错误，使 WithDetails 派生出的错误仍然能被 errors.Is 识别
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Key == e.Key
+}
+
+// WithDetails 返回携带附加信息的副本，不修改原有的错误
+func (e *Error) WithDetails(details any) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// WithParams 返回携带消息占位符取值的副本，不修改原有的错误
+func (e *Error) WithParams(params map[string]any) *Error {
+	c := *e
+	c.Params = params
+	return &c
+}
+
+// 通用错误
+var (
+	ErrInternal        = New(10000, http.StatusInternalServerError, "common.internal", "服务器内部错误")
+	ErrParams          = New(10001, http.StatusBadRequest, "common.params", "参数格式错误")
+	ErrTooManyRequests = New(10002, http.StatusTooManyRequests, "common.too_many_requests", "请求过于频繁，请稍后再试")
+	ErrNotFound        = New(10003, http.StatusNotFound, "common.not_found", "资源不存在")
+	ErrForbidden       = New(10004, http.StatusForbidden, "common.forbidden", "没有权限")
+)
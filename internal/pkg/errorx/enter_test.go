@@ -0,0 +1,57 @@
+package errorx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNewDuplicateCode(t *testing.T) {
+	New(99001, http.StatusBadRequest, "test.first", "first")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("New with a duplicate code should panic")
+		}
+	}()
+	New(99001, http.StatusBadRequest, "test.second", "second")
+}
+
+func TestIs(t *testing.T) {
+	e := New(99002, http.StatusNotFound, "test.not_found", "not found")
+	other := New(99003, http.StatusNotFound, "test.other", "other")
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "same", err: e, want: true},
+		{name: "with details", err: e.WithDetails("detail"), want: true},
+		{name: "with params", err: e.WithParams(map[string]any{"n": 1}), want: true},
+		{name: "wrapped", err: fmt.Errorf("wrap: %w", e), want: true},
+		{name: "other", err: other},
+		{name: "plain", err: errors.New("not found")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, e); got != tt.want {
+				t.Fatalf("errors.Is = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithDetailsCopies(t *testing.T) {
+	e := New(99004, http.StatusBadRequest, "test.details", "details")
+	d := e.WithDetails("detail")
+	p := e.WithParams(map[string]any{"n": 1})
+	if e.Details != nil || e.Params != nil {
+		t.Fatal("WithDetails and WithParams should not modify the original error")
+	}
+	if d.Details != "detail" || d.Code != e.Code || d.Status != e.Status || d.Key != e.Key {
+		t.Fatalf("unexpected copy %+v", d)
+	}
+	if p.Params["n"] != 1 {
+		t.Fatalf("unexpected params %v", p.Params)
+	}
+}
@@ -0,0 +1,172 @@
+package hashx
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"nurture/internal/config"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// 密码以 PHC 字符串格式存储：$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+const prefix = "$argon2id$"
+
+// 默认参数参考 OWASP 推荐值，配置中未填写时使用
+const (
+	defaultTime    uint32 = 3
+	defaultMemory  uint32 = 64 * 1024 // KiB
+	defaultThreads uint8  = 2
+	defaultSaltLen uint32 = 16
+	defaultKeyLen  uint32 = 32
+)
+
+var (
+	ErrHashFormat  = errors.New("password hash format is invalid")
+	ErrHashVersion = errors.New("argon2 version is incompatible")
+)
+
+type params struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	saltLen uint32
+	keyLen  uint32
+}
+
+// currentParams 读取配置中的 argon2 参数，零值回退为默认值
+func currentParams() params {
+	c := config.Conf.Auth.Argon2
+	p := params{
+		time:    c.Time,
+		memory:  c.Memory,
+		threads: c.Threads,
+		saltLen: c.SaltLen,
+		keyLen:  c.KeyLen,
+	}
+	if p.time == 0 {
+		p.time = defaultTime
+	}
+	if p.memory == 0 {
+		p.memory = defaultMemory
+	}
+	if p.threads == 0 {
+		p.threads = defaultThreads
+	}
+	if p.saltLen == 0 {
+		p.saltLen = defaultSaltLen
+	}
+	if p.keyLen == 0 {
+		p.keyLen = defaultKeyLen
+	}
+	return p
+}
+
+// HashPassword 使用 argon2id 和随机盐对密码进行哈希
+func HashPassword(password string) (string, error) {
+	p := currentParams()
+	salt := make([]byte, p.saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, p.keyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		prefix,
+		argon2.Version,
+		p.memory,
+		p.time,
+		p.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// VerifyPassword 校验密码是否与存储的值匹配
+// rehash 为 true 表示存储的值是历史明文或参数已过时，调用方应在校验通过后重新哈希并写回
+func VerifyPassword(password, encoded string) (ok bool, rehash bool, err error) {
+	if !IsHashed(encoded) {
+		// 兼容迁移前以明文存储的密码
+		ok = subtle.ConstantTimeCompare([]byte(password), []byte(encoded)) == 1
+		return ok, ok, nil
+	}
+	p, salt, key, err := decode(encoded)
+	if err != nil {
+		return false, false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, p.keyLen)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false, nil
+	}
+	return true, p != currentParams(), nil
+}
+
+// dummy 缓存按当前参数生成的哈希，参数变化时重新生成
+var dummy struct {
+	mu      sync.Mutex
+	params  params
+	encoded string
+}
+
+// dummyHash 返回与当前参数一致的固定哈希
+func dummyHash() (string, error) {
+	dummy.mu.Lock()
+	defer dummy.mu.Unlock()
+	p := currentParams()
+	if dummy.encoded == "" || dummy.params != p {
+		encoded, err := HashPassword("")
+		if err != nil {
+			return "", err
+		}
+		dummy.params, dummy.encoded = p, encoded
+	}
+	return dummy.encoded, nil
+}
+
+// VerifyDummy 对固定的哈希校验一次密码并丢弃结果
+// 用户不存在时调用，使耗时与真实校验一致，避免通过响应时间判断账号是否存在
+func VerifyDummy(password string) {
+	encoded, err := dummyHash()
+	if err != nil {
+		return
+	}
+	_, _, _ = VerifyPassword(password, encoded)
+}
+
+// IsHashed 判断存储的值是否已经是 argon2id 哈希
+func IsHashed(encoded string) bool {
+	return strings.HasPrefix(encoded, prefix)
+}
+
+func decode(encoded string) (params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params{}, nil, nil, ErrHashFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params{}, nil, nil, ErrHashFormat
+	}
+	if version != argon2.Version {
+		return params{}, nil, nil, ErrHashVersion
+	}
+	var p params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return params{}, nil, nil, ErrHashFormat
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params{}, nil, nil, ErrHashFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params{}, nil, nil, ErrHashFormat
+	}
+	p.saltLen = uint32(len(salt))
+	p.keyLen = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,142 @@
+package hashx
+
+import (
+	"nurture/internal/config"
+	"nurture/internal/pkg/testx"
+	"strings"
+	"testing"
+)
+
+// setParams 使用较小的代价参数加快测试
+func setParams(t *testing.T, time, memory uint32) {
+	testx.Set(t, &config.Conf.Auth.Argon2, config.Argon2{Time: time, Memory: memory, Threads: 1})
+}
+
+func TestHashPassword(t *testing.T) {
+	setParams(t, 1, 64)
+	a, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(a, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("unexpected encoding %q", a)
+	}
+	b, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Fatal("same password should use different salts")
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	setParams(t, 1, 64)
+	encoded, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		password   string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{name: "correct", password: "secret", wantOK: true},
+		{name: "wrong", password: "Secret"},
+		{name: "empty", password: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := VerifyPassword(tt.password, encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Fatalf("got ok=%t rehash=%t, want ok=%t rehash=%t", ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestVerifyPasswordRehash(t *testing.T) {
+	setParams(t, 1, 64)
+	encoded, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 参数调整后旧哈希仍然可以校验，但需要重新哈希
+	setParams(t, 2, 64)
+	ok, rehash, err := VerifyPassword("secret", encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || !rehash {
+		t.Fatalf("got ok=%t rehash=%t, want ok and rehash", ok, rehash)
+	}
+	// 密码错误时不要求重新哈希
+	ok, rehash, err = VerifyPassword("wrong", encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || rehash {
+		t.Fatalf("got ok=%t rehash=%t, want neither", ok, rehash)
+	}
+}
+
+func TestVerifyPasswordPlaintext(t *testing.T) {
+	// 迁移前的明文密码校验通过后需要重新哈希
+	ok, rehash, err := VerifyPassword("secret", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || !rehash {
+		t.Fatalf("got ok=%t rehash=%t, want ok and rehash", ok, rehash)
+	}
+	ok, rehash, err = VerifyPassword("other", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || rehash {
+		t.Fatalf("got ok=%t rehash=%t, want neither", ok, rehash)
+	}
+}
+
+func TestVerifyPasswordMalformed(t *testing.T) {
+	for _, encoded := range []string{
+		"$argon2id$",
+		"$argon2id$v=19$m=64,t=1,p=1$salt",
+		"$argon2id$v=18$m=64,t=1,p=1$c2FsdHNhbHQ$aGFzaA",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHQ$aGFzaA",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$aGFzaA",
+	} {
+		if _, _, err := VerifyPassword("secret", encoded); err == nil {
+			t.Errorf("VerifyPassword(%q) should fail", encoded)
+		}
+	}
+}
+
+func TestDummyHash(t *testing.T) {
+	setParams(t, 1, 64)
+	a, err := dummyHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(a, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("unexpected encoding %q", a)
+	}
+	// 参数不变时复用同一个哈希
+	if b, _ := dummyHash(); b != a {
+		t.Fatal("dummy hash should be reused")
+	}
+	// 参数变化后按新参数重新生成，保证耗时与真实校验一致
+	setParams(t, 2, 64)
+	b, err := dummyHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b, "$argon2id$v=19$m=64,t=2,p=1$") {
+		t.Fatalf("unexpected encoding %q", b)
+	}
+	VerifyDummy("secret")
+}
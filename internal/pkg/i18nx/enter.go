@@ -0,0 +1,128 @@
+package i18nx
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 支持的语言，未协商出结果时使用 DefaultLocale
+const (
+	ZhCN          = "zh-CN"
+	EnUS          = "en-US"
+	DefaultLocale = ZhCN
+)
+
+// Supported 按优先级排列的支持语言
+var Supported = []string{ZhCN, EnUS}
+
+// 消息目录，每种语言一个 JSON 文件，key 为消息的国际化 key，值中的 {name} 为占位符
+//
+//go:embed locales/*.json
+var catalogFS embed.FS
+
+var catalogs = loadCatalogs()
+
+type localeKey struct{}
+
+func loadCatalogs() map[string]map[string]string {
+	c := make(map[string]map[string]string, len(Supported))
+	for _, locale := range Supported {
+		data, err := catalogFS.ReadFile("locales/" + locale + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("load i18n catalog %s error: %v", locale, err))
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("parse i18n catalog %s error: %v", locale, err))
+		}
+		c[locale] = messages
+	}
+	return c
+}
+
+// Lookup 查找 locale 下 key 对应的消息并替换占位符，缺失时回退到默认语言，都没有时 ok 为 false
+func Lookup(locale, key string, params map[string]any) (msg string, ok bool) {
+	msg, ok = catalogs[locale][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		return "", false
+	}
+	for name, value := range params {
+		msg = strings.ReplaceAll(msg, "{"+name+"}", fmt.Sprint(value))
+	}
+	return msg, true
+}
+
+// T 翻译消息，找不到时返回 key 本身
+func T(locale, key string, params map[string]any) string {
+	if msg, ok := Lookup(locale, key, params); ok {
+		return msg
+	}
+	return key
+}
+
+// Normalize 把 "en"、"en_us"、"EN-US" 等写法规范为支持的语言，不支持时 ok 为 false
+func Normalize(locale string) (string, bool) {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if locale == "" {
+		return "", false
+	}
+	for _, s := range Supported {
+		if strings.EqualFold(locale, s) {
+			return s, true
+		}
+	}
+	// 只匹配主语言，例如 zh-TW、zh-Hans 都归到 zh-CN
+	lang := strings.SplitN(locale, "-", 2)[0]
+	for _, s := range Supported {
+		if strings.EqualFold(lang, strings.SplitN(s, "-", 2)[0]) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate 根据 Accept-Language 选择语言，按 q 值从高到低匹配，没有匹配时返回默认语言
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := DefaultLocale, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, q := parseLanguage(part)
+		if q <= bestQ {
+			continue
+		}
+		if locale, ok := Normalize(tag); ok {
+			best, bestQ = locale, q
+		}
+	}
+	return best
+}
+
+// parseLanguage 解析 "en-US;q=0.8"，q 缺省为 1，格式错误时为 0
+func parseLanguage(part string) (string, float64) {
+	tag, param, _ := strings.Cut(strings.TrimSpace(part), ";")
+	q := 1.0
+	if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+		if _, err := fmt.Sscanf(v, "%g", &q); err != nil {
+			q = 0
+		}
+	}
+	return strings.TrimSpace(tag), q
+}
+
+// WithLocale 把语言写入 ctx
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext 读取 ctx 中的语言，未设置时返回默认语言
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
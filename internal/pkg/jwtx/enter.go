@@ -3,13 +3,16 @@ package jwtx
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"nurture/internal/config"
 	"nurture/internal/constant"
+	"nurture/internal/pkg/errorx"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 type Role int
@@ -21,71 +24,78 @@ const (
 )
 
 type MyClaims struct {
-	UserID string `json:"user_id"`
-	Role   Role   `json:"role"`
+	UserID       string `json:"user_id"`
+	Role         Role   `json:"role"`
+	SessionID    string `json:"sid"`
+	TokenVersion int32  `json:"ver"`
 	jwt.RegisteredClaims
 }
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   Role   `json:"role"`
+	UserID       string `json:"user_id"`
+	Role         Role   `json:"role"`
+	SessionID    string `json:"sid"`
+	TokenVersion int32  `json:"ver"`
 }
 
 var (
-	ErrDefault          = errors.New("jwt default error")
-	ErrTokenEmpty       = errors.New("token is empty")
-	ErrTokenExpired     = errors.New("token has expired")
-	ErrTokenInvalid     = errors.New("token is invalid")
-	ErrPermissionDenied = errors.New("permission denied")
+	ErrDefault          = errorx.New(20000, http.StatusUnauthorized, "token.default", "jwt default error")
+	ErrTokenEmpty       = errorx.New(20001, http.StatusUnauthorized, "token.empty", "token is empty")
+	ErrTokenExpired     = errorx.New(20002, http.StatusUnauthorized, "token.expired", "token has expired")
+	ErrTokenInvalid     = errorx.New(20003, http.StatusUnauthorized, "token.invalid", "token is invalid")
+	ErrSessionRevoked   = errorx.New(20004, http.StatusUnauthorized, "token.session_revoked", "session has been revoked")
+	ErrTokenRevoked     = errorx.New(20005, http.StatusUnauthorized, "token.revoked", "token has been revoked")
+	ErrPermissionDenied = errorx.New(20008, http.StatusForbidden, "token.permission_denied", "permission denied")
 )
 
 func GenToken(c Claims) (string, error) {
-	secret := config.Conf.Auth.AccessSecret
 	expiredTime := config.Conf.Auth.AccessExpire
 	// 创建一个我们自己的声明
 	claims := MyClaims{
 		c.UserID,
 		c.Role,
+		c.SessionID,
+		c.TokenVersion,
 		jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiredTime) * time.Second)), // 过期时间
 			Issuer:    "Nurture",
 		},
 	}
-	// 使用指定的签名方法创建签名对象
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	// 使用指定的secret签名并获得完整的编码后的字符串token
-	return token.SignedString([]byte(secret))
+	// 使用当前激活的密钥签名，未配置密钥时使用 HS256 + access_secret
+	return keys.signToken(claims)
 }
 
-func ParseToken(c *gin.Context) (string, Role, error) {
+func ParseToken(c *gin.Context) (*MyClaims, error) {
 	data := c.GetHeader("Authorization")
 	if data == "" {
-		return "", 0, ErrTokenEmpty
+		return nil, ErrTokenEmpty
 	}
 	token := strings.TrimPrefix(data, "Bearer ")
 	// 解析token
 	var claims MyClaims
-	t, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.Conf.Auth.AccessSecret), nil
-	})
+	t, err := jwt.ParseWithClaims(token, &claims, keys.keyFunc)
 	if err != nil {
+		if errors.Is(err, ErrTokenInvalid) {
+			return nil, ErrTokenInvalid
+		}
 		if strings.Contains(err.Error(), "token is expired") {
-			return "", 0, ErrTokenExpired
+			return nil, ErrTokenExpired
 		}
 		if strings.Contains(err.Error(), "signature is invalid") {
-			return "", 0, ErrTokenInvalid
+			return nil, ErrTokenInvalid
 		}
 		if strings.Contains(err.Error(), "token contains an invalid") {
-			return "", 0, ErrTokenInvalid
+			return nil, ErrTokenInvalid
 		}
 		fmt.Println(err)
-		return "", 0, ErrDefault
+		return nil, ErrDefault
 	}
 	if claims, ok := t.Claims.(*MyClaims); ok && t.Valid {
-		return claims.UserID, claims.Role, nil
+		return claims, nil
 	}
-	return "", 0, ErrDefault
+	return nil, ErrDefault
 }
 
 // 必须使用了鉴权中间件才能用
@@ -109,3 +119,14 @@ func GetRole(c *gin.Context) Role {
 	}
 	return 0
 }
+
+// 必须使用了鉴权中间件才能用
+func GetSessionID(c *gin.Context) string {
+	if data, exists := c.Get(constant.TOKEN_SESSION_ID); exists {
+		sessionID, ok := data.(string)
+		if ok {
+			return sessionID
+		}
+	}
+	return ""
+}
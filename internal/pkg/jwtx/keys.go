@@ -0,0 +1,199 @@
+package jwtx
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"nurture/internal/config"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signingKey 一把签名密钥，private 为空表示只用于校验（已轮换下线但仍需校验存量令牌）
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// keySet 当前加载的所有密钥，未配置非对称密钥时回退为 HS256 + access_secret
+type keySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+var keys = &keySet{keys: map[string]*signingKey{}}
+
+// InitKeys 从配置的文件中加载签名密钥，配置错误时直接 panic
+func InitKeys() {
+	ks, err := loadKeySet(config.Conf.Auth)
+	if err != nil {
+		panic(fmt.Sprintf("load jwt keys error: %v", err))
+	}
+	keys = ks
+}
+
+func loadKeySet(auth config.Auth) (*keySet, error) {
+	ks := &keySet{keys: map[string]*signingKey{}}
+	for _, kc := range auth.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("kid %s: %w", kc.Kid, err)
+		}
+		if _, ok := ks.keys[k.kid]; ok {
+			return nil, fmt.Errorf("kid %s is duplicated", k.kid)
+		}
+		ks.keys[k.kid] = k
+	}
+	if auth.ActiveKid == "" {
+		if len(ks.keys) > 0 {
+			return nil, fmt.Errorf("active_kid is required when keys are configured")
+		}
+		return ks, nil
+	}
+	active, ok := ks.keys[auth.ActiveKid]
+	if !ok {
+		return nil, fmt.Errorf("active kid %s is not configured", auth.ActiveKid)
+	}
+	if active.private == nil {
+		return nil, fmt.Errorf("active kid %s has no private key", auth.ActiveKid)
+	}
+	ks.active = active
+	return ks, nil
+}
+
+func loadKey(kc config.SigningKey) (*signingKey, error) {
+	if kc.Kid == "" {
+		return nil, fmt.Errorf("kid is empty")
+	}
+	if kc.PrivateKey == "" && kc.PublicKey == "" {
+		return nil, fmt.Errorf("private_key or public_key is required")
+	}
+	k := &signingKey{kid: kc.Kid}
+	switch kc.Alg {
+	case jwt.SigningMethodRS256.Alg():
+		k.method = jwt.SigningMethodRS256
+		if kc.PrivateKey != "" {
+			data, err := os.ReadFile(kc.PrivateKey)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			k.private, k.public = private, &private.PublicKey
+		} else {
+			data, err := os.ReadFile(kc.PublicKey)
+			if err != nil {
+				return nil, err
+			}
+			if k.public, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+				return nil, err
+			}
+		}
+	case jwt.SigningMethodEdDSA.Alg():
+		k.method = jwt.SigningMethodEdDSA
+		if kc.PrivateKey != "" {
+			data, err := os.ReadFile(kc.PrivateKey)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			signer, ok := private.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("private key is not an ed25519 key")
+			}
+			k.private, k.public = signer, signer.Public()
+		} else {
+			data, err := os.ReadFile(kc.PublicKey)
+			if err != nil {
+				return nil, err
+			}
+			if k.public, err = jwt.ParseEdPublicKeyFromPEM(data); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported alg %q", kc.Alg)
+	}
+	return k, nil
+}
+
+// signToken 使用当前激活的密钥签名，并在头部写入 kid
+func (ks *keySet) signToken(claims jwt.Claims) (string, error) {
+	if ks.active == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(config.Conf.Auth.AccessSecret))
+	}
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	token.Header["kid"] = ks.active.kid
+	return token.SignedString(ks.active.private)
+}
+
+// keyFunc 根据令牌头部的 kid 选择校验密钥，并要求签名算法与密钥一致
+func (ks *keySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if ks.active == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrTokenInvalid
+		}
+		return []byte(config.Conf.Auth.AccessSecret), nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok || token.Method.Alg() != k.method.Alg() {
+		return nil, ErrTokenInvalid
+	}
+	return k.public, nil
+}
+
+// JWK 单个公钥的 JSON Web Key 表示
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS JSON Web Key Set，供其他服务校验令牌
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// GetJWKS 返回所有非对称密钥的公钥，包括只用于校验的旧密钥；HS256 模式下为空
+func GetJWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(keys.keys))}
+	for _, k := range keys.keys {
+		jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
@@ -0,0 +1,73 @@
+package lifecyclex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// StopFunc 停止一个组件，应在 ctx 到期前返回
+type StopFunc func(ctx context.Context) error
+
+type component struct {
+	name string
+	stop StopFunc
+}
+
+// Manager 按注册的逆序停止组件：先注册的组件（例如数据库、日志）被后注册的组件依赖，最后停止
+type Manager struct {
+	mu         sync.Mutex
+	log        *zap.SugaredLogger
+	components []component
+}
+
+func New(log *zap.SugaredLogger) *Manager {
+	return &Manager{log: log}
+}
+
+// Add 注册需要在退出时停止的组件
+func (m *Manager) Add(name string, stop StopFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, component{name: name, stop: stop})
+}
+
+// Shutdown 逐个停止组件，某个组件出错或超时不影响后续组件的停止
+// ctx 是整个退出过程的期限，到期后剩余的组件仍会被调用，以便尽量释放资源
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	components := m.components
+	m.components = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		m.log.Infof("正在停止%s", c.name)
+		if err := c.stop(ctx); err != nil {
+			m.log.Errorf("停止%s失败:%v", c.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Wait 把没有 ctx 参数的阻塞式停止函数（例如等待 goroutine 退出）转换为 StopFunc，ctx 到期时不再等待
+func Wait(stop func()) StopFunc {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			stop()
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package lifecyclex
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestShutdownOrder(t *testing.T) {
+	m := New(zap.NewNop().Sugar())
+	var order []string
+	for _, name := range []string{"log", "db", "redis", "http"} {
+		m.Add(name, func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"http", "redis", "db", "log"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("stop order = %v, want %v", order, want)
+	}
+	// 组件只会被停止一次
+	order = nil
+	if err := m.Shutdown(context.Background()); err != nil || order != nil {
+		t.Fatalf("second shutdown: err=%v order=%v", err, order)
+	}
+}
+
+func TestShutdownContinuesAfterError(t *testing.T) {
+	m := New(zap.NewNop().Sugar())
+	errDB := errors.New("db busy")
+	var stopped []string
+	m.Add("db", func(ctx context.Context) error {
+		stopped = append(stopped, "db")
+		return errDB
+	})
+	m.Add("http", func(ctx context.Context) error {
+		stopped = append(stopped, "http")
+		return nil
+	})
+	m.Add("worker", func(ctx context.Context) error {
+		stopped = append(stopped, "worker")
+		return errors.New("worker stuck")
+	})
+	err := m.Shutdown(context.Background())
+	if !reflect.DeepEqual(stopped, []string{"worker", "http", "db"}) {
+		t.Fatalf("stopped = %v, want all components", stopped)
+	}
+	if !errors.Is(err, errDB) {
+		t.Fatalf("err = %v, want it to wrap the db error", err)
+	}
+	if got := err.Error(); got != "worker: worker stuck\ndb: db busy" {
+		t.Fatalf("err = %q", got)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	m := New(zap.NewNop().Sugar())
+	release := make(chan struct{})
+	defer close(release)
+	var dbCalled bool
+	m.Add("db", func(ctx context.Context) error {
+		dbCalled = true
+		return nil
+	})
+	m.Add("worker", Wait(func() { <-release }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := m.Shutdown(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown took %s, should give up at the deadline", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	// 超时后剩余的组件仍然会被停止
+	if !dbCalled {
+		t.Fatal("components after a timeout should still be stopped")
+	}
+}
+
+func TestWait(t *testing.T) {
+	var stopped bool
+	if err := Wait(func() { stopped = true })(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !stopped {
+		t.Fatal("stop should have been called")
+	}
+}
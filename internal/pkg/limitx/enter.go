@@ -0,0 +1,26 @@
+package limitx
+
+import (
+	"context"
+	"nurture/internal/config"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Limiter 滑动窗口限流器，内存和 Redis 两种实现行为保持一致
+type Limiter interface {
+	// Allow 判断 key 在最近 window 时间内的请求次数是否小于 limit
+	// 放行时记录本次请求；被限流时返回还需等待多久才能再次请求
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (ok bool, retryAfter time.Duration, err error)
+	// Check 与 Allow 的判断相同，但不记录本次请求，用于多个限流条件都通过后才计数
+	Check(ctx context.Context, key string, limit int, window time.Duration) (ok bool, retryAfter time.Duration, err error)
+}
+
+// InitLimiter 根据配置选择限流器，开启 Redis 时使用 Redis，否则使用进程内存
+func InitLimiter(rdb redis.Cmdable) Limiter {
+	if config.Conf.Redis.Enable && rdb != nil {
+		return NewRedisLimiter(rdb)
+	}
+	return NewMemoryLimiter()
+}
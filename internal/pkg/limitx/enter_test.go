@@ -0,0 +1,124 @@
+package limitx
+
+import (
+	"context"
+	"nurture/internal/pkg/testx"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	testLimiter(t, NewMemoryLimiter())
+}
+
+func TestRedisLimiter(t *testing.T) {
+	_, rdb := testx.Redis(t)
+	testLimiter(t, NewRedisLimiter(rdb))
+}
+
+// testLimiter 两种实现需要通过同样的用例
+func testLimiter(t *testing.T, l Limiter) {
+	ctx := context.Background()
+	const window = 200 * time.Millisecond
+
+	t.Run("limit", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			ok, wait, err := l.Allow(ctx, "limit", 3, window)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok || wait != 0 {
+				t.Fatalf("request %d: got ok=%t wait=%s, want allowed", i+1, ok, wait)
+			}
+		}
+		ok, wait, err := l.Allow(ctx, "limit", 3, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			t.Fatal("request over the limit should be rejected")
+		}
+		if wait <= 0 || wait > window {
+			t.Fatalf("retry after %s, want within (0, %s]", wait, window)
+		}
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		if ok, _, err := l.Allow(ctx, "a", 1, window); err != nil || !ok {
+			t.Fatalf("first request on a: ok=%t err=%v", ok, err)
+		}
+		if ok, _, err := l.Allow(ctx, "b", 1, window); err != nil || !ok {
+			t.Fatalf("first request on b: ok=%t err=%v", ok, err)
+		}
+		if ok, _, err := l.Allow(ctx, "a", 1, window); err != nil || ok {
+			t.Fatalf("second request on a: ok=%t err=%v", ok, err)
+		}
+	})
+
+	t.Run("window slides", func(t *testing.T) {
+		if ok, _, err := l.Allow(ctx, "slide", 1, window); err != nil || !ok {
+			t.Fatalf("first request: ok=%t err=%v", ok, err)
+		}
+		ok, wait, err := l.Allow(ctx, "slide", 1, window)
+		if err != nil || ok {
+			t.Fatalf("second request: ok=%t err=%v", ok, err)
+		}
+		time.Sleep(wait + 20*time.Millisecond)
+		if ok, _, err := l.Allow(ctx, "slide", 1, window); err != nil || !ok {
+			t.Fatalf("request after the window: ok=%t err=%v", ok, err)
+		}
+	})
+
+	t.Run("check does not record", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if ok, _, err := l.Check(ctx, "check", 1, window); err != nil || !ok {
+				t.Fatalf("check %d: ok=%t err=%v", i+1, ok, err)
+			}
+		}
+		if ok, _, err := l.Allow(ctx, "check", 1, window); err != nil || !ok {
+			t.Fatalf("request after checks: ok=%t err=%v", ok, err)
+		}
+		ok, wait, err := l.Check(ctx, "check", 1, window)
+		if err != nil || ok {
+			t.Fatalf("check over the limit: ok=%t err=%v", ok, err)
+		}
+		if wait <= 0 || wait > window {
+			t.Fatalf("retry after %s, want within (0, %s]", wait, window)
+		}
+	})
+
+	t.Run("rejected requests are not counted", func(t *testing.T) {
+		if ok, _, err := l.Allow(ctx, "reject", 1, window); err != nil || !ok {
+			t.Fatalf("first request: ok=%t err=%v", ok, err)
+		}
+		time.Sleep(window / 2)
+		// 被拒绝的请求不延长窗口，第一次请求过期后即可再次请求
+		if ok, _, err := l.Allow(ctx, "reject", 1, window); err != nil || ok {
+			t.Fatalf("second request: ok=%t err=%v", ok, err)
+		}
+		time.Sleep(window/2 + 20*time.Millisecond)
+		if ok, _, err := l.Allow(ctx, "reject", 1, window); err != nil || !ok {
+			t.Fatalf("request after the first expired: ok=%t err=%v", ok, err)
+		}
+	})
+}
+
+func TestMemoryLimiterCleanup(t *testing.T) {
+	l := &MemoryLimiter{}
+	ctx := context.Background()
+	if ok, _, err := l.Allow(ctx, "k", 1, time.Millisecond); err != nil || !ok {
+		t.Fatalf("ok=%t err=%v", ok, err)
+	}
+	w, _ := l.m.Load("k")
+	l.sweep(time.Now().Add(time.Second))
+	if _, ok := l.m.Load("k"); ok {
+		t.Fatal("expired window should be removed")
+	}
+	// 持有旧窗口的调用方会重新获取新的窗口
+	if !w.dead {
+		t.Fatal("removed window should be marked dead")
+	}
+	if ok, _, err := l.Allow(ctx, "k", 1, time.Millisecond); err != nil || !ok {
+		t.Fatalf("ok=%t err=%v", ok, err)
+	}
+}
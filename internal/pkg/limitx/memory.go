@@ -0,0 +1,100 @@
+package limitx
+
+import (
+	"context"
+	"nurture/internal/pkg/syncx"
+	"sync"
+	"time"
+)
+
+// 清理过期窗口的间隔
+const cleanupInterval = time.Minute
+
+type memoryWindow struct {
+	mu     sync.Mutex
+	window time.Duration
+	hits   []time.Time // 按时间升序排列的请求时间
+	dead   bool        // 已被清理协程移除，持有旧引用的调用方需要重新获取
+}
+
+// prune 移除窗口之外的请求记录，调用方需持有锁
+func (w *memoryWindow) prune(now time.Time) {
+	i := 0
+	for i < len(w.hits) && !w.hits[i].After(now.Add(-w.window)) {
+		i++
+	}
+	w.hits = w.hits[i:]
+}
+
+// MemoryLimiter 基于进程内存的滑动窗口限流器，只适用于单实例部署
+type MemoryLimiter struct {
+	m syncx.Map[string, *memoryWindow]
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	l := &MemoryLimiter{}
+	go l.cleanup()
+	return l
+}
+
+var _ Limiter = (*MemoryLimiter)(nil)
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	ok, wait := l.allow(key, limit, window, true)
+	return ok, wait, nil
+}
+
+func (l *MemoryLimiter) Check(_ context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	ok, wait := l.allow(key, limit, window, false)
+	return ok, wait, nil
+}
+
+// allow 判断是否放行，record 为 true 时记录放行的请求
+func (l *MemoryLimiter) allow(key string, limit int, window time.Duration, record bool) (bool, time.Duration) {
+	w := l.load(key, window)
+	defer w.mu.Unlock()
+	now := time.Now()
+	w.window = window
+	w.prune(now)
+	if len(w.hits) < limit {
+		if record {
+			w.hits = append(w.hits, now)
+		}
+		return true, 0
+	}
+	return false, w.hits[0].Add(window).Sub(now)
+}
+
+// load 获取 key 对应的窗口并加锁，跳过已被清理的窗口
+func (l *MemoryLimiter) load(key string, window time.Duration) *memoryWindow {
+	for {
+		w, _ := l.m.LoadOrStore(key, &memoryWindow{window: window})
+		w.mu.Lock()
+		if !w.dead {
+			return w
+		}
+		w.mu.Unlock()
+	}
+}
+
+// cleanup 定期删除已经没有请求记录的 key，防止内存无限增长
+func (l *MemoryLimiter) cleanup() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		l.sweep(now)
+	}
+}
+
+func (l *MemoryLimiter) sweep(now time.Time) {
+	l.m.Range(func(key string, w *memoryWindow) bool {
+		w.mu.Lock()
+		w.prune(now)
+		if len(w.hits) == 0 {
+			w.dead = true
+			l.m.CompareAndDelete(key, w)
+		}
+		w.mu.Unlock()
+		return true
+	})
+}
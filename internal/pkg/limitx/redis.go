@@ -0,0 +1,67 @@
+package limitx
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// allowScript 使用有序集合实现滑动窗口，score 为请求时间（毫秒）
+// 放行返回 0，被限流返回还需等待的毫秒数；ARGV[5] 为 1 时记录放行的请求
+var allowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], 0, now - window)
+if redis.call("ZCARD", KEYS[1]) < limit then
+	if ARGV[5] == "1" then
+		redis.call("ZADD", KEYS[1], now, ARGV[4])
+		redis.call("PEXPIRE", KEYS[1], window)
+	end
+	return 0
+end
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+return tonumber(oldest[2]) + window - now
+`)
+
+// RedisLimiter 基于 Redis 的滑动窗口限流器，支持多实例部署
+type RedisLimiter struct {
+	rdb redis.Cmdable
+}
+
+func NewRedisLimiter(rdb redis.Cmdable) *RedisLimiter {
+	return &RedisLimiter{rdb: rdb}
+}
+
+var _ Limiter = (*RedisLimiter)(nil)
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	return l.allow(ctx, key, limit, window, true)
+}
+
+func (l *RedisLimiter) Check(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	return l.allow(ctx, key, limit, window, false)
+}
+
+func (l *RedisLimiter) allow(ctx context.Context, key string, limit int, window time.Duration, record bool) (bool, time.Duration, error) {
+	flag := 0
+	if record {
+		flag = 1
+	}
+	wait, err := allowScript.Run(ctx, l.rdb, []string{key},
+		time.Now().UnixMilli(),
+		window.Milliseconds(),
+		limit,
+		uuid.NewString(), // 同一毫秒内的多次请求需要不同的 member
+		flag,
+	).Int64()
+	if err != nil {
+		return false, 0, err
+	}
+	if wait <= 0 {
+		return true, 0, nil
+	}
+	return false, time.Duration(wait) * time.Millisecond, nil
+}
@@ -0,0 +1,54 @@
+package lockx
+
+import (
+	"context"
+	"nurture/internal/config"
+	"nurture/internal/constant"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Policy 锁定策略：连续失败 Threshold 次后锁定 Base，此后每多失败一次锁定时长翻倍，最长 Max
+type Policy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+	Window    time.Duration // 失败次数在最后一次失败后保留的时间
+}
+
+// lockFor 计算第 fails 次失败后需要锁定的时长，未达到阈值时返回 0
+func (p Policy) lockFor(fails int) time.Duration {
+	if fails < p.Threshold {
+		return 0
+	}
+	d := p.Base
+	for i := p.Threshold; i < fails && d < p.Max; i++ {
+		d *= 2
+	}
+	return min(d, p.Max)
+}
+
+// Locker 记录连续失败次数并按指数退避锁定，内存和 Redis 两种实现行为保持一致
+type Locker interface {
+	// Locked 返回 key 剩余的锁定时间，未锁定时返回 0
+	Locked(ctx context.Context, key string) (time.Duration, error)
+	// Fail 记录一次失败，返回本次失败触发的锁定时长，未触发锁定时返回 0
+	Fail(ctx context.Context, key string) (time.Duration, error)
+	// Reset 成功后清空失败记录和锁定状态
+	Reset(ctx context.Context, key string) error
+}
+
+// InitLocker 根据配置选择账号锁定的存储，开启 Redis 时使用 Redis，否则使用进程内存
+func InitLocker(rdb redis.Cmdable) Locker {
+	policy := Policy{
+		Threshold: constant.LOGIN_MAX_FAILS,
+		Base:      constant.LOGIN_LOCK_BASE,
+		Max:       constant.LOGIN_LOCK_MAX,
+		Window:    constant.LOGIN_FAIL_WINDOW,
+	}
+	if config.Conf.Redis.Enable && rdb != nil {
+		return NewRedisLocker(rdb, policy)
+	}
+	return NewMemoryLocker(policy)
+}
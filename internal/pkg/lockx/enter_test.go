@@ -0,0 +1,189 @@
+package lockx
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/pkg/testx"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	Threshold: 3,
+	Base:      time.Minute,
+	Max:       4 * time.Minute,
+	Window:    time.Hour,
+}
+
+func TestPolicyLockFor(t *testing.T) {
+	tests := []struct {
+		fails int
+		want  time.Duration
+	}{
+		{fails: 1, want: 0},
+		{fails: 2, want: 0},
+		{fails: 3, want: time.Minute},
+		{fails: 4, want: 2 * time.Minute},
+		{fails: 5, want: 4 * time.Minute},
+		{fails: 6, want: 4 * time.Minute},
+		{fails: 100, want: 4 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := testPolicy.lockFor(tt.fails); got != tt.want {
+			t.Errorf("lockFor(%d) = %s, want %s", tt.fails, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryLocker(t *testing.T) {
+	testLocker(t, NewMemoryLocker(testPolicy))
+}
+
+func TestRedisLocker(t *testing.T) {
+	_, rdb := testx.Redis(t)
+	testLocker(t, NewRedisLocker(rdb, testPolicy))
+}
+
+// testLocker 两种实现需要通过同样的用例
+func testLocker(t *testing.T, l Locker) {
+	ctx := context.Background()
+
+	t.Run("lock after threshold", func(t *testing.T) {
+		for i := 1; i < testPolicy.Threshold; i++ {
+			d, err := l.Fail(ctx, "user")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d != 0 {
+				t.Fatalf("fail %d locked for %s, want no lock", i, d)
+			}
+		}
+		if locked, err := l.Locked(ctx, "user"); err != nil || locked != 0 {
+			t.Fatalf("locked=%s err=%v before threshold", locked, err)
+		}
+		d, err := l.Fail(ctx, "user")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != testPolicy.Base {
+			t.Fatalf("locked for %s, want %s", d, testPolicy.Base)
+		}
+		locked, err := l.Locked(ctx, "user")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if locked <= 0 || locked > testPolicy.Base {
+			t.Fatalf("remaining lock %s, want within (0, %s]", locked, testPolicy.Base)
+		}
+		// 继续失败时锁定时长翻倍
+		if d, err := l.Fail(ctx, "user"); err != nil || d != 2*testPolicy.Base {
+			t.Fatalf("locked for %s err=%v, want %s", d, err, 2*testPolicy.Base)
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		for i := 0; i < testPolicy.Threshold; i++ {
+			if _, err := l.Fail(ctx, "reset"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := l.Reset(ctx, "reset"); err != nil {
+			t.Fatal(err)
+		}
+		if locked, err := l.Locked(ctx, "reset"); err != nil || locked != 0 {
+			t.Fatalf("locked=%s err=%v after reset", locked, err)
+		}
+		// 失败次数也被清空
+		if d, err := l.Fail(ctx, "reset"); err != nil || d != 0 {
+			t.Fatalf("locked for %s err=%v after reset, want no lock", d, err)
+		}
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		for i := 0; i < testPolicy.Threshold; i++ {
+			if _, err := l.Fail(ctx, "a"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if locked, err := l.Locked(ctx, "b"); err != nil || locked != 0 {
+			t.Fatalf("locked=%s err=%v for another key", locked, err)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		if locked, err := l.Locked(ctx, "unknown"); err != nil || locked != 0 {
+			t.Fatalf("locked=%s err=%v", locked, err)
+		}
+		if err := l.Reset(ctx, "unknown"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestMemoryLockerWindow(t *testing.T) {
+	l := NewMemoryLocker(Policy{Threshold: 2, Base: time.Minute, Max: time.Minute, Window: 50 * time.Millisecond})
+	ctx := context.Background()
+	if _, err := l.Fail(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	// 超过窗口期后重新计数
+	if d, err := l.Fail(ctx, "k"); err != nil || d != 0 {
+		t.Fatalf("locked for %s err=%v, want no lock", d, err)
+	}
+}
+
+func TestMemoryLockerSweep(t *testing.T) {
+	policy := Policy{Threshold: 2, Base: 2 * time.Hour, Max: 2 * time.Hour, Window: time.Hour}
+	l := &MemoryLocker{policy: policy, maxKeys: maxMemoryKeys}
+	ctx := context.Background()
+	if _, err := l.Fail(ctx, "expired"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < policy.Threshold; i++ {
+		if _, err := l.Fail(ctx, "locked"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 窗口期已过但仍在锁定中的记录需要保留到锁定结束
+	l.sweep(time.Now().Add(policy.Window))
+	if _, ok := l.m.Load("expired"); ok {
+		t.Fatal("expired record should be removed")
+	}
+	if _, ok := l.m.Load("locked"); !ok {
+		t.Fatal("locked record should be kept")
+	}
+	if n := l.n.Load(); n != 1 {
+		t.Fatalf("record count = %d, want 1", n)
+	}
+	l.sweep(time.Now().Add(policy.Max + time.Second))
+	if _, ok := l.m.Load("locked"); ok {
+		t.Fatal("record should be removed after the lock ends")
+	}
+	if n := l.n.Load(); n != 0 {
+		t.Fatalf("record count = %d, want 0", n)
+	}
+}
+
+func TestMemoryLockerCap(t *testing.T) {
+	l := &MemoryLocker{policy: testPolicy, maxKeys: 2}
+	ctx := context.Background()
+	for _, key := range []string{"a", "b"} {
+		if _, err := l.Fail(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := l.Fail(ctx, "c"); !errors.Is(err, ErrFull) {
+		t.Fatalf("err = %v, want ErrFull", err)
+	}
+	// 已有的记录不受上限影响
+	if _, err := l.Fail(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reset(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Fail(ctx, "c"); err != nil {
+		t.Fatalf("err = %v after a record was reset", err)
+	}
+}
@@ -0,0 +1,142 @@
+package lockx
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/pkg/syncx"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// 清理过期记录的间隔
+	cleanupInterval = time.Minute
+	// 最多同时保存的失败记录数，防止大量不同的 key 耗尽内存
+	maxMemoryKeys = 100000
+)
+
+// ErrFull 失败记录已达上限，本次失败没有被记录
+var ErrFull = errors.New("lockx: 失败记录已达上限")
+
+type memoryRecord struct {
+	mu          sync.Mutex
+	fails       int
+	lastFail    time.Time
+	lockedUntil time.Time
+	dead        bool // 已被清理协程移除，持有旧引用的调用方需要重新获取
+}
+
+// expired 失败记录已经超过保留时间且不在锁定中，调用方需持有锁
+func (r *memoryRecord) expired(now time.Time, window time.Duration) bool {
+	return now.Sub(r.lastFail) >= window && !now.Before(r.lockedUntil)
+}
+
+// MemoryLocker 基于进程内存的账号锁定，只适用于单实例部署
+type MemoryLocker struct {
+	m       syncx.Map[string, *memoryRecord]
+	n       atomic.Int64 // 当前保存的记录数
+	maxKeys int64
+	policy  Policy
+}
+
+func NewMemoryLocker(policy Policy) *MemoryLocker {
+	l := &MemoryLocker{policy: policy, maxKeys: maxMemoryKeys}
+	go l.cleanup()
+	return l
+}
+
+var _ Locker = (*MemoryLocker)(nil)
+
+func (l *MemoryLocker) Locked(_ context.Context, key string) (time.Duration, error) {
+	r, ok := l.m.Load(key)
+	if !ok {
+		return 0, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return max(time.Until(r.lockedUntil), 0), nil
+}
+
+func (l *MemoryLocker) Fail(_ context.Context, key string) (time.Duration, error) {
+	r, err := l.load(key)
+	if err != nil {
+		return 0, err
+	}
+	defer r.mu.Unlock()
+	now := time.Now()
+	if now.Sub(r.lastFail) > l.policy.Window {
+		r.fails = 0
+	}
+	r.fails++
+	r.lastFail = now
+	d := l.policy.lockFor(r.fails)
+	if d > 0 {
+		r.lockedUntil = now.Add(d)
+	}
+	return d, nil
+}
+
+func (l *MemoryLocker) Reset(_ context.Context, key string) error {
+	r, ok := l.m.Load(key)
+	if !ok {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l.remove(key, r)
+	return nil
+}
+
+// load 获取 key 对应的记录并加锁，跳过已被清理的记录，记录数达到上限时不再创建新记录
+func (l *MemoryLocker) load(key string) (*memoryRecord, error) {
+	for {
+		r, ok := l.m.Load(key)
+		if !ok {
+			if l.n.Load() >= l.maxKeys {
+				return nil, ErrFull
+			}
+			var loaded bool
+			r, loaded = l.m.LoadOrStore(key, &memoryRecord{})
+			if !loaded {
+				l.n.Add(1)
+			}
+		}
+		r.mu.Lock()
+		if !r.dead {
+			return r, nil
+		}
+		r.mu.Unlock()
+	}
+}
+
+// remove 删除记录，调用方需持有记录的锁
+func (l *MemoryLocker) remove(key string, r *memoryRecord) {
+	if r.dead {
+		return
+	}
+	r.dead = true
+	if l.m.CompareAndDelete(key, r) {
+		l.n.Add(-1)
+	}
+}
+
+// cleanup 定期删除超过保留时间且不在锁定中的记录，防止内存无限增长
+func (l *MemoryLocker) cleanup() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		l.sweep(now)
+	}
+}
+
+func (l *MemoryLocker) sweep(now time.Time) {
+	l.m.Range(func(key string, r *memoryRecord) bool {
+		r.mu.Lock()
+		if r.expired(now, l.policy.Window) {
+			l.remove(key, r)
+		}
+		r.mu.Unlock()
+		return true
+	})
+}
@@ -0,0 +1,60 @@
+package lockx
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisLocker 基于 Redis 的账号锁定，支持多实例部署
+type RedisLocker struct {
+	rdb    redis.Cmdable
+	policy Policy
+}
+
+func NewRedisLocker(rdb redis.Cmdable, policy Policy) *RedisLocker {
+	return &RedisLocker{rdb: rdb, policy: policy}
+}
+
+var _ Locker = (*RedisLocker)(nil)
+
+func (l *RedisLocker) Locked(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := l.rdb.PTTL(ctx, lockKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// key 不存在时 PTTL 返回负数
+	return max(ttl, 0), nil
+}
+
+func (l *RedisLocker) Fail(ctx context.Context, key string) (time.Duration, error) {
+	var incr *redis.IntCmd
+	_, err := l.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, failKey(key))
+		pipe.PExpire(ctx, failKey(key), l.policy.Window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	d := l.policy.lockFor(int(incr.Val()))
+	if d > 0 {
+		if err := l.rdb.Set(ctx, lockKey(key), 1, d).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return d, nil
+}
+
+func (l *RedisLocker) Reset(ctx context.Context, key string) error {
+	return l.rdb.Del(ctx, failKey(key), lockKey(key)).Err()
+}
+
+func failKey(key string) string {
+	return key + ":fails"
+}
+
+func lockKey(key string) string {
+	return key + ":locked"
+}
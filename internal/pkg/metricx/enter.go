@@ -0,0 +1,155 @@
+package metricx
+
+import (
+	"errors"
+	"net/http"
+	"nurture/internal/pkg/errorx"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "nurture"
+
+	OutcomeSuccess = "success"
+	// OutcomeError 不是 errorx.Error 的错误，通常是内部错误
+	OutcomeError = "error"
+	// unmatchedRoute 没有匹配到路由的请求统一记录为该值，避免路径作为标签导致时间序列无限增长
+	unmatchedRoute = "unmatched"
+)
+
+// Registry 本服务的指标，不使用 prometheus 的默认 Registry，避免依赖库注册的指标混入
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+// HTTP 请求的速率、错误和耗时
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP 请求数",
+	}, []string{"method", "route", "status"})
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP 请求耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	httpInFlight = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "正在处理的 HTTP 请求数",
+	})
+)
+
+// 业务指标
+var (
+	logins = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "登录次数，outcome 为 success 或错误的 key",
+	}, []string{"login_type", "outcome"})
+	registrations = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "注册次数，outcome 为 success 或错误的 key",
+	}, []string{"outcome"})
+	codesSent = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "codes_sent_total",
+		Help:      "发送的验证码数量",
+	}, []string{"purpose", "outcome"})
+	codesVerified = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "codes_verified_total",
+		Help:      "验证码校验次数，outcome 为 success 或 failure",
+	}, []string{"purpose", "outcome"})
+	emailSendDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "email_send_duration_seconds",
+		Help:      "邮件投递耗时",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"template", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler 以 Prometheus 文本格式输出 Registry 中的指标
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// HTTPStart 请求开始时调用，返回的函数在请求结束时调用
+// route 是匹配到的路由模板（例如 /api/admin/users/:user_id），为空表示没有匹配到路由
+func HTTPStart() func(method, route string, status int) {
+	start := time.Now()
+	httpInFlight.Inc()
+	return func(method, route string, status int) {
+		httpInFlight.Dec()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Login 记录一次登录，loginType 需要是已知的登录方式，避免任意输入成为标签
+func Login(loginType string, err error) {
+	logins.WithLabelValues(loginType, Outcome(err)).Inc()
+}
+
+func Registration(err error) {
+	registrations.WithLabelValues(Outcome(err)).Inc()
+}
+
+// CodeSent 记录一次验证码发送，purpose 取验证码存储 key 的前缀，例如 login_code
+func CodeSent(storeKey string, err error) {
+	codesSent.WithLabelValues(codePurpose(storeKey), Outcome(err)).Inc()
+}
+
+func CodeVerified(storeKey string, ok bool) {
+	outcome := OutcomeSuccess
+	if !ok {
+		outcome = "failure"
+	}
+	codesVerified.WithLabelValues(codePurpose(storeKey), outcome).Inc()
+}
+
+func EmailSent(template string, duration time.Duration, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	}
+	emailSendDuration.WithLabelValues(template, outcome).Observe(duration.Seconds())
+}
+
+// Outcome 把业务结果转换为标签值：成功为 success，业务错误为错误的 key，其余为 error
+func Outcome(err error) string {
+	if err == nil {
+		return OutcomeSuccess
+	}
+	var e *errorx.Error
+	if errors.As(err, &e) {
+		return e.Key
+	}
+	return OutcomeError
+}
+
+// codePurpose 验证码存储 key 的格式为 purpose:参数，去掉其中的邮箱和用户ID
+func codePurpose(storeKey string) string {
+	purpose, _, _ := strings.Cut(storeKey, ":")
+	return purpose
+}
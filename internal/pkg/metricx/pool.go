@@ -0,0 +1,118 @@
+package metricx
+
+import (
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pgxPoolCollector 在抓取时读取 pgxpool 的连接池状态
+type pgxPoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	canceledAcquire   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+}
+
+// RegisterPgxPool 注册数据库连接池的指标
+func RegisterPgxPool(pool *pgxpool.Pool) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+	Registry.MustRegister(&pgxPoolCollector{
+		pool:              pool,
+		acquiredConns:     desc("acquired_conns", "正在使用的连接数"),
+		idleConns:         desc("idle_conns", "空闲连接数"),
+		totalConns:        desc("total_conns", "连接总数"),
+		maxConns:          desc("max_conns", "最大连接数"),
+		acquireCount:      desc("acquire_total", "获取连接的次数"),
+		acquireDuration:   desc("acquire_duration_seconds_total", "获取连接累计等待的时间"),
+		canceledAcquire:   desc("canceled_acquire_total", "获取连接时 ctx 被取消的次数"),
+		emptyAcquireCount: desc("empty_acquire_total", "连接池没有空闲连接需要等待的次数"),
+	})
+}
+
+func (c *pgxPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.canceledAcquire
+	ch <- c.emptyAcquireCount
+}
+
+func (c *pgxPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquire, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+}
+
+// redisPool 能提供连接池状态的 redis 客户端，*redis.Client 和 *redis.ClusterClient 都满足
+type redisPool interface {
+	PoolStats() *redis.PoolStats
+}
+
+// redisPoolCollector 在抓取时读取 redis 客户端的连接池状态
+type redisPoolCollector struct {
+	pool redisPool
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+// RegisterRedisPool 注册 redis 连接池的指标，rdb 为 nil（未启用 redis）时不注册
+func RegisterRedisPool(rdb redis.Cmdable) {
+	pool, ok := rdb.(redisPool)
+	if !ok {
+		return
+	}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", name), help, nil, nil)
+	}
+	Registry.MustRegister(&redisPoolCollector{
+		pool:       pool,
+		hits:       desc("hits_total", "从连接池中取到空闲连接的次数"),
+		misses:     desc("misses_total", "连接池中没有空闲连接的次数"),
+		timeouts:   desc("timeouts_total", "等待连接超时的次数"),
+		totalConns: desc("total_conns", "连接总数"),
+		idleConns:  desc("idle_conns", "空闲连接数"),
+		staleConns: desc("stale_conns_total", "因过期被关闭的连接数"),
+	})
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
@@ -0,0 +1,145 @@
+package otelx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nurture/internal/config"
+	"os"
+	"path/filepath"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+
+	defaultServiceName = "nurture"
+)
+
+// Shutdown 导出剩余的 span 并释放导出器，应在程序退出前调用
+type Shutdown func(ctx context.Context) error
+
+// InitTracer 根据配置设置全局的 TracerProvider 和 traceparent 传播器
+// 不开启时保留 otel 默认的空实现，各处的埋点不产生开销
+func InitTracer(conf config.Telemetry, env string) Shutdown {
+	if !conf.Enable {
+		return func(context.Context) error { return nil }
+	}
+	exporter, closeExporter, err := newExporter(conf)
+	if err != nil {
+		panic(fmt.Sprintf("init tracer error: %v", err))
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(ServiceName(conf)),
+		semconv.DeploymentEnvironmentName(env),
+	)
+	ratio := conf.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeExporter())
+	}
+}
+
+// ServiceName 返回上报的服务名，未配置时为 nurture
+func ServiceName(conf config.Telemetry) string {
+	if conf.ServiceName == "" {
+		return defaultServiceName
+	}
+	return conf.ServiceName
+}
+
+// newExporter 创建导出器，file 导出器额外返回关闭文件的函数
+func newExporter(conf config.Telemetry) (sdktrace.SpanExporter, func() error, error) {
+	noClose := func() error { return nil }
+	switch conf.Exporter {
+	case "", ExporterOTLP:
+		exporter, err := newOTLPExporter(conf)
+		return exporter, noClose, err
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exporter, noClose, err
+	case ExporterFile:
+		if conf.File == "" {
+			return nil, nil, errors.New("telemetry.file is required for file exporter")
+		}
+		if err := os.MkdirAll(filepath.Dir(conf.File), 0o755); err != nil {
+			return nil, nil, err
+		}
+		f, err := os.OpenFile(conf.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exporter, f.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown telemetry exporter %q", conf.Exporter)
+	}
+}
+
+func newOTLPExporter(conf config.Telemetry) (sdktrace.SpanExporter, error) {
+	ctx := context.Background()
+	switch conf.Protocol {
+	case "", ProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(conf.Headers)}
+		if conf.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(conf.Endpoint))
+		}
+		if conf.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ProtocolHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(conf.Headers)}
+		if conf.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(conf.Endpoint))
+		}
+		if conf.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown telemetry protocol %q", conf.Protocol)
+	}
+}
+
+// Tracer 返回 nurture 下 name 模块使用的 Tracer
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer("nurture/" + name)
+}
+
+// EndSpan 按 err 设置 span 的状态后结束 span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"nurture/internal/config"
 
+	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,6 +16,10 @@ func InitPgsql() *pgxpool.Pool {
 	if err != nil {
 		panic(fmt.Sprintf("parse pgsql config error: %v", err))
 	}
+	if config.Conf.Telemetry.Enable {
+		// 只记录 SQL 语句，不记录查询参数
+		poolConfig.ConnConfig.Tracer = otelpgx.NewTracer(otelpgx.WithTrimSQLInSpanName())
+	}
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
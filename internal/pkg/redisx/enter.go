@@ -36,6 +36,9 @@ func InitRedis() redis.Cmdable {
 		TLSConfig:          nil,
 		Limiter:            nil,
 	})
+	if config.Conf.Telemetry.Enable {
+		client.AddHook(newTraceHook())
+	}
 	if _, err := client.Ping(context.Background()).Result(); err != nil {
 		panic(fmt.Sprintf("redis init error:%v", err))
 	}
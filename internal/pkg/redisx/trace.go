@@ -0,0 +1,77 @@
+package redisx
+
+import (
+	"context"
+	"nurture/internal/pkg/otelx"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceHook 为每条命令创建 span，只记录命令名，不记录键和参数，避免验证码等数据写入链路
+type traceHook struct {
+	tracer trace.Tracer
+}
+
+func newTraceHook() *traceHook {
+	return &traceHook{tracer: otelx.Tracer("redisx")}
+}
+
+var _ redis.Hook = (*traceHook)(nil)
+
+func (h *traceHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx, nil
+	}
+	ctx, _ = h.tracer.Start(ctx, "redis "+cmd.FullName(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameRedis, semconv.DBOperationName(cmd.FullName())),
+	)
+	return ctx, nil
+}
+
+func (h *traceHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	otelx.EndSpan(trace.SpanFromContext(ctx), cmdErr(cmd))
+	return nil
+}
+
+func (h *traceHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx, nil
+	}
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.FullName())
+	}
+	ctx, _ = h.tracer.Start(ctx, "redis pipeline",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameRedis,
+			semconv.DBOperationName(strings.Join(names, " ")),
+			attribute.Int("db.redis.num_cmd", len(cmds)),
+		),
+	)
+	return ctx, nil
+}
+
+func (h *traceHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if err = cmdErr(cmd); err != nil {
+			break
+		}
+	}
+	otelx.EndSpan(trace.SpanFromContext(ctx), err)
+	return nil
+}
+
+// cmdErr 键不存在不算错误
+func cmdErr(cmd redis.Cmder) error {
+	if err := cmd.Err(); err != nil && err != redis.Nil {
+		return err
+	}
+	return nil
+}
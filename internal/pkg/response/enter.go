@@ -1,25 +1,51 @@
 package response
 
 import (
+	"errors"
+	"net/http"
+	"nurture/internal/pkg/errorx"
+	"nurture/internal/pkg/i18nx"
+
 	"github.com/gin-gonic/gin"
 )
 
+const CodeSuccess = 0
+
 type Body struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
 }
 
+// Response 统一的响应格式
+// 错误为 *errorx.Error 时使用其 HTTP 状态码、业务码和附加信息，提示信息按请求的语言翻译；
+// 其他未归类的错误统一按内部错误返回，不把内部细节暴露给调用方
 func Response(c *gin.Context, resp interface{}, err error) {
-	var body Body
-	if err != nil {
-		body.Code = -1
-		body.Message = err.Error()
-		body.Data = nil
-	} else {
-		body.Code = 0
-		body.Message = "OK"
-		body.Data = resp
+	if err == nil {
+		c.JSON(http.StatusOK, Body{
+			Code:    CodeSuccess,
+			Message: "OK",
+			Data:    resp,
+		})
+		return
+	}
+	var e *errorx.Error
+	if !errors.As(err, &e) {
+		e = errorx.ErrInternal
 	}
-	c.JSON(200, body)
+	msg, ok := i18nx.Lookup(i18nx.FromContext(c.Request.Context()), e.Key, e.Params)
+	if !ok {
+		msg = e.Message
+	}
+	c.JSON(e.Status, Body{
+		Code:    e.Code,
+		Message: msg,
+		Data:    e.Details,
+	})
+}
+
+// Abort 返回错误响应并终止后续的处理函数，供中间件使用
+func Abort(c *gin.Context, err error) {
+	Response(c, nil, err)
+	c.Abort()
 }
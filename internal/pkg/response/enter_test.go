@@ -0,0 +1,131 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"nurture/internal/pkg/errorx"
+	"nurture/internal/pkg/i18nx"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		locale     string
+		resp       any
+		err        error
+		wantStatus int
+		wantCode   int
+		wantMsg    string
+		wantData   any
+	}{
+		{
+			name:       "success",
+			resp:       map[string]any{"id": "1"},
+			wantStatus: http.StatusOK,
+			wantCode:   CodeSuccess,
+			wantMsg:    "OK",
+			wantData:   map[string]any{"id": "1"},
+		},
+		{
+			name:       "app error",
+			locale:     i18nx.ZhCN,
+			err:        errorx.ErrTooManyRequests,
+			wantStatus: http.StatusTooManyRequests,
+			wantCode:   10002,
+			wantMsg:    i18nx.T(i18nx.ZhCN, "common.too_many_requests", nil),
+		},
+		{
+			name:       "translated",
+			locale:     i18nx.EnUS,
+			err:        errorx.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   10003,
+			wantMsg:    i18nx.T(i18nx.EnUS, "common.not_found", nil),
+		},
+		{
+			name:       "details",
+			locale:     i18nx.ZhCN,
+			err:        errorx.ErrTooManyRequests.WithDetails(map[string]any{"retry_after": 3}),
+			wantStatus: http.StatusTooManyRequests,
+			wantCode:   10002,
+			wantMsg:    i18nx.T(i18nx.ZhCN, "common.too_many_requests", nil),
+			wantData:   map[string]any{"retry_after": float64(3)},
+		},
+		{
+			name:       "wrapped app error",
+			locale:     i18nx.ZhCN,
+			err:        fmt.Errorf("wrap: %w", errorx.ErrForbidden),
+			wantStatus: http.StatusForbidden,
+			wantCode:   10004,
+			wantMsg:    i18nx.T(i18nx.ZhCN, "common.forbidden", nil),
+		},
+		{
+			// 未归类的错误按内部错误返回，不暴露原始信息
+			name:       "plain error",
+			locale:     i18nx.ZhCN,
+			err:        errors.New("pq: connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   10000,
+			wantMsg:    i18nx.T(i18nx.ZhCN, "common.internal", nil),
+		},
+		{
+			// 没有翻译的 key 使用默认的提示信息
+			name:       "missing key",
+			locale:     i18nx.EnUS,
+			err:        errorx.New(99101, http.StatusConflict, "test.missing_key", "默认信息"),
+			wantStatus: http.StatusConflict,
+			wantCode:   99101,
+			wantMsg:    "默认信息",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.locale != "" {
+				req = req.WithContext(i18nx.WithLocale(req.Context(), tt.locale))
+			}
+			c.Request = req
+			Response(c, tt.resp, tt.err)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var body struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+				Data    any    `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tt.wantCode || body.Message != tt.wantMsg {
+				t.Fatalf("got code=%d message=%q, want code=%d message=%q", body.Code, body.Message, tt.wantCode, tt.wantMsg)
+			}
+			if fmt.Sprint(body.Data) != fmt.Sprint(tt.wantData) {
+				t.Fatalf("data = %v, want %v", body.Data, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestAbort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	Abort(c, errorx.ErrForbidden)
+	if !c.IsAborted() {
+		t.Fatal("Abort should stop the handler chain")
+	}
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
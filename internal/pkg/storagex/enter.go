@@ -0,0 +1,56 @@
+package storagex
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"nurture/internal/config"
+	"path"
+	"strings"
+)
+
+// 存储驱动，由 storage.driver 配置
+const (
+	DriverLocal = "local" // 本地磁盘，通过静态路由对外提供访问
+	DriverS3    = "s3"    // S3 兼容的对象存储，例如 AWS S3、MinIO
+)
+
+// Storage 对象存储，key 为以 / 分隔的相对路径，例如 avatar/<user_id>/<name>.jpg
+type Storage interface {
+	// Put 保存对象并返回可以公开访问的 URL
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (string, error)
+	Delete(ctx context.Context, key string) error
+	// Key 把 Put 返回的 URL 还原为 key，不是本存储的 URL 时返回 false
+	Key(url string) (string, bool)
+	// DeletePrefix 删除 prefix 目录下的所有对象，例如注销账号时删除 avatar/<user_id>
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// InitStorage 按配置创建对象存储，配置错误时直接 panic
+func InitStorage(conf config.Storage) Storage {
+	s, err := NewStorage(conf)
+	if err != nil {
+		panic(fmt.Sprintf("init storage error: %v", err))
+	}
+	return s
+}
+
+func NewStorage(conf config.Storage) (Storage, error) {
+	switch conf.Driver {
+	case "", DriverLocal:
+		return NewLocalStorage(conf.Local)
+	case DriverS3:
+		return NewS3Storage(conf.S3)
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %q", conf.Driver)
+	}
+}
+
+// keyFromURL 去掉 URL 的公开地址前缀得到 key，拒绝跳出目录或不规范的 key
+func keyFromURL(url, prefix string) (string, bool) {
+	key, ok := strings.CutPrefix(url, prefix+"/")
+	if !ok || key == "" || path.Clean("/" + key)[1:] != key {
+		return "", false
+	}
+	return key, true
+}
@@ -0,0 +1,87 @@
+package storagex
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// testStorage 两种实现需要通过同样的用例，exists 检查 key 对应的对象是否存在
+func testStorage(t *testing.T, s Storage, exists func(key string) bool) {
+	ctx := context.Background()
+	put := func(t *testing.T, key string) string {
+		t.Helper()
+		url, err := s.Put(ctx, key, strings.NewReader(key), int64(len(key)), "image/png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return url
+	}
+
+	t.Run("put", func(t *testing.T) {
+		url := put(t, "avatar/u1/a.png")
+		if !exists("avatar/u1/a.png") {
+			t.Fatal("object should exist after Put")
+		}
+		// Put 返回的 URL 可以还原为 key
+		if key, ok := s.Key(url); !ok || key != "avatar/u1/a.png" {
+			t.Fatalf("Key(%q) = %q, %t", url, key, ok)
+		}
+		if _, ok := s.Key("https://other.example.com/avatar/u1/a.png"); ok {
+			t.Fatal("URL of another host should not be accepted")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		put(t, "avatar/u2/a.png")
+		if err := s.Delete(ctx, "avatar/u2/a.png"); err != nil {
+			t.Fatal(err)
+		}
+		if exists("avatar/u2/a.png") {
+			t.Fatal("object should be deleted")
+		}
+		// 删除不存在的对象不报错
+		if err := s.Delete(ctx, "avatar/u2/a.png"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("delete prefix", func(t *testing.T) {
+		put(t, "avatar/u3/a.png")
+		put(t, "avatar/u3/b.png")
+		put(t, "avatar/u30/a.png")
+		if err := s.DeletePrefix(ctx, "avatar/u3/"); err != nil {
+			t.Fatal(err)
+		}
+		if exists("avatar/u3/a.png") || exists("avatar/u3/b.png") {
+			t.Fatal("objects under the prefix should be deleted")
+		}
+		if !exists("avatar/u30/a.png") {
+			t.Fatal("objects of other prefixes should be kept")
+		}
+	})
+}
+
+func TestKeyFromURL(t *testing.T) {
+	const prefix = "http://localhost:8080/uploads"
+	tests := []struct {
+		url  string
+		key  string
+		want bool
+	}{
+		{url: prefix + "/avatar/u1/a.png", key: "avatar/u1/a.png", want: true},
+		{url: "https://cdn.example.com/avatar/u1/a.png"},
+		{url: prefix + "/"},
+		{url: prefix + "/../a.png"},
+		{url: prefix + "/avatar/../../a.png"},
+		{url: prefix + "/avatar//a.png"},
+		{url: prefix + "avatar/a.png"},
+		{url: ""},
+	}
+	for _, tt := range tests {
+		key, ok := keyFromURL(tt.url, prefix)
+		if ok != tt.want || key != tt.key {
+			t.Errorf("keyFromURL(%q) = %q, %t, want %q, %t", tt.url, key, ok, tt.key, tt.want)
+		}
+	}
+}
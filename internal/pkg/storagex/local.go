@@ -0,0 +1,96 @@
+package storagex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"nurture/internal/config"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidKey = errors.New("storage key is invalid")
+
+// LocalStorage 把对象保存在本地目录中，由 router 以 URLPrefix 为前缀挂载静态路由
+type LocalStorage struct {
+	dir       string
+	urlPrefix string
+}
+
+func NewLocalStorage(conf config.LocalStorage) (*LocalStorage, error) {
+	if conf.Dir == "" || conf.URLPrefix == "" {
+		return nil, errors.New("storage.local.dir and storage.local.url_prefix are required")
+	}
+	if err := os.MkdirAll(conf.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{
+		dir:       conf.Dir,
+		urlPrefix: strings.TrimSuffix(conf.URLPrefix, "/"),
+	}, nil
+}
+
+func (ls *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (string, error) {
+	file, err := ls.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+	// 先写入临时文件再重命名，避免读到写了一半的文件
+	tmp := fmt.Sprintf("%s.%s.tmp", file, uuid.NewString())
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, file)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return ls.urlPrefix + "/" + key, nil
+}
+
+func (ls *LocalStorage) Delete(ctx context.Context, key string) error {
+	file, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (ls *LocalStorage) Key(url string) (string, bool) {
+	return keyFromURL(url, ls.urlPrefix)
+}
+
+func (ls *LocalStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	dir, err := ls.path(strings.TrimSuffix(prefix, "/"))
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// path 把 key 转换为目录下的文件路径，拒绝跳出目录的 key
+func (ls *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)[1:]
+	if clean == "" || clean != key {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(ls.dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,70 @@
+package storagex
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLocalStorage(t *testing.T) (*LocalStorage, string) {
+	t.Helper()
+	dir := t.TempDir()
+	ls, err := NewLocalStorage(config.LocalStorage{Dir: dir, URLPrefix: "http://localhost:8080/uploads/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ls, dir
+}
+
+func TestLocalStorage(t *testing.T) {
+	ls, dir := newTestLocalStorage(t)
+	testStorage(t, ls, func(key string) bool {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key)))
+		return err == nil
+	})
+}
+
+func TestLocalStorageOverwrite(t *testing.T) {
+	ls, dir := newTestLocalStorage(t)
+	ctx := context.Background()
+	for _, content := range []string{"old", "new"} {
+		url, err := ls.Put(ctx, "avatar/u1/a.png", strings.NewReader(content), int64(len(content)), "image/png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if url != "http://localhost:8080/uploads/avatar/u1/a.png" {
+			t.Fatalf("url = %q", url)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "avatar", "u1", "a.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Fatalf("content = %q, want new", data)
+	}
+	// 不留下临时文件
+	if entries, _ := os.ReadDir(filepath.Join(dir, "avatar", "u1")); len(entries) != 1 {
+		t.Fatalf("got %d files, want 1", len(entries))
+	}
+}
+
+func TestLocalStorageInvalidKey(t *testing.T) {
+	ls, _ := newTestLocalStorage(t)
+	ctx := context.Background()
+	for _, key := range []string{"", "/abs.png", "../escape.png", "avatar/../../escape.png", "avatar//a.png", "avatar/"} {
+		if _, err := ls.Put(ctx, key, strings.NewReader("x"), 1, "image/png"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): err = %v, want ErrInvalidKey", key, err)
+		}
+		if err := ls.Delete(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Delete(%q): err = %v, want ErrInvalidKey", key, err)
+		}
+	}
+	if err := ls.DeletePrefix(ctx, "../"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("DeletePrefix: err = %v, want ErrInvalidKey", err)
+	}
+}
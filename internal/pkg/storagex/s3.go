@@ -0,0 +1,90 @@
+package storagex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"nurture/internal/config"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage S3 兼容的对象存储，bucket 需要允许公开读取，或在前面配置 CDN
+type S3Storage struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+func NewS3Storage(conf config.S3Storage) (*S3Storage, error) {
+	if conf.Endpoint == "" || conf.Bucket == "" {
+		return nil, errors.New("storage.s3.endpoint and storage.s3.bucket are required")
+	}
+	client, err := minio.New(conf.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(conf.AccessKey, conf.SecretKey, ""),
+		Secure: conf.UseSSL,
+		Region: conf.Region,
+		// MinIO 等自建服务通常不支持虚拟主机风格的 bucket 域名
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	publicURL := conf.PublicURL
+	if publicURL == "" {
+		scheme := "http://"
+		if conf.UseSSL {
+			scheme = "https://"
+		}
+		publicURL = scheme + conf.Endpoint + "/" + conf.Bucket
+	}
+	return &S3Storage{
+		client:    client,
+		bucket:    conf.Bucket,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}, nil
+}
+
+func (ss *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (string, error) {
+	_, err := ss.client.PutObject(ctx, ss.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	if err != nil {
+		return "", err
+	}
+	return ss.publicURL + "/" + key, nil
+}
+
+func (ss *S3Storage) Delete(ctx context.Context, key string) error {
+	return ss.client.RemoveObject(ctx, ss.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (ss *S3Storage) Key(url string) (string, bool) {
+	return keyFromURL(url, ss.publicURL)
+}
+
+func (ss *S3Storage) DeletePrefix(ctx context.Context, prefix string) error {
+	if prefix = strings.TrimSuffix(prefix, "/"); prefix == "" {
+		return errors.New("storage prefix is empty")
+	}
+	objects := ss.client.ListObjects(ctx, ss.bucket, minio.ListObjectsOptions{
+		Prefix:    prefix + "/",
+		Recursive: true,
+	})
+	// 需要读完错误通道，否则删除的 goroutine 会一直阻塞
+	var err error
+	for e := range ss.client.RemoveObjects(ctx, ss.bucket, objects, minio.RemoveObjectsOptions{}) {
+		if err == nil {
+			err = e.Err
+		}
+	}
+	return err
+}
+
+var (
+	_ Storage = (*LocalStorage)(nil)
+	_ Storage = (*S3Storage)(nil)
+)
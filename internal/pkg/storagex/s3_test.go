@@ -0,0 +1,226 @@
+package storagex
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"nurture/internal/config"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testBucket = "nurture"
+
+// s3Object 桩服务中保存的对象
+type s3Object struct {
+	data         []byte
+	contentType  string
+	cacheControl string
+}
+
+// s3Stub 只实现 S3Storage 用到的几个接口：PutObject、DeleteObject、ListObjectsV2 和 DeleteObjects
+type s3Stub struct {
+	mu      sync.Mutex
+	objects map[string]s3Object
+}
+
+func newS3Stub(t *testing.T) (*s3Stub, *httptest.Server) {
+	stub := &s3Stub{objects: map[string]s3Object{}}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	return stub, srv
+}
+
+func (s *s3Stub) object(key string) (s3Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	return obj, ok
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != testBucket {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPut && key != "":
+		data, err := readPayload(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = s3Object{
+			data:         data,
+			contentType:  r.Header.Get("Content-Type"),
+			cacheControl: r.Header.Get("Cache-Control"),
+		}
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodDelete && key != "":
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && key == "" && query.Get("list-type") == "2":
+		var result struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Name     string
+			Prefix   string
+			KeyCount int
+			Contents []struct{ Key string }
+		}
+		result.Name, result.Prefix = bucket, query.Get("prefix")
+		for k := range s.objects {
+			if strings.HasPrefix(k, result.Prefix) {
+				result.Contents = append(result.Contents, struct{ Key string }{k})
+			}
+		}
+		sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+		result.KeyCount = len(result.Contents)
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodPost && key == "" && query.Has("delete"):
+		var req struct {
+			Object []struct{ Key string }
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var result struct {
+			XMLName xml.Name `xml:"DeleteResult"`
+			Deleted []struct{ Key string }
+		}
+		for _, obj := range req.Object {
+			delete(s.objects, obj.Key)
+			result.Deleted = append(result.Deleted, obj)
+		}
+		xml.NewEncoder(w).Encode(result)
+	default:
+		http.Error(w, "NotImplemented", http.StatusNotImplemented)
+	}
+}
+
+// readPayload 读取请求体，非 TLS 连接上 minio-go 以 aws-chunked 编码分块上传
+func readPayload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+	var data []byte
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data, nil
+		}
+		chunk := make([]byte, size+2) // 数据块以 \r\n 结尾
+		if _, err := io.ReadFull(br, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+func newTestS3Storage(t *testing.T, publicURL string) (*S3Storage, *s3Stub, string) {
+	t.Helper()
+	stub, srv := newS3Stub(t)
+	endpoint := strings.TrimPrefix(srv.URL, "http://")
+	ss, err := NewS3Storage(config.S3Storage{
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    testBucket,
+		AccessKey: "access",
+		SecretKey: "secret",
+		PublicURL: publicURL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ss, stub, endpoint
+}
+
+func TestS3Storage(t *testing.T) {
+	ss, stub, _ := newTestS3Storage(t, "")
+	testStorage(t, ss, func(key string) bool {
+		_, ok := stub.object(key)
+		return ok
+	})
+}
+
+func TestS3StoragePut(t *testing.T) {
+	ss, stub, endpoint := newTestS3Storage(t, "")
+	url, err := ss.Put(context.Background(), "avatar/u1/a.png", strings.NewReader("image"), 5, "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 未配置 public_url 时使用 endpoint/bucket
+	if want := "http://" + endpoint + "/" + testBucket + "/avatar/u1/a.png"; url != want {
+		t.Fatalf("url = %q, want %q", url, want)
+	}
+	obj, ok := stub.object("avatar/u1/a.png")
+	if !ok {
+		t.Fatal("object should be uploaded")
+	}
+	if string(obj.data) != "image" || obj.contentType != "image/png" {
+		t.Fatalf("got data=%q content type=%q", obj.data, obj.contentType)
+	}
+	if !strings.Contains(obj.cacheControl, "immutable") {
+		t.Fatalf("cache control = %q", obj.cacheControl)
+	}
+}
+
+func TestS3StoragePublicURL(t *testing.T) {
+	ss, _, _ := newTestS3Storage(t, "https://cdn.example.com/")
+	url, err := ss.Put(context.Background(), "avatar/u1/a.png", strings.NewReader("image"), 5, "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://cdn.example.com/avatar/u1/a.png" {
+		t.Fatalf("url = %q", url)
+	}
+	if key, ok := ss.Key(url); !ok || key != "avatar/u1/a.png" {
+		t.Fatalf("Key(%q) = %q, %t", url, key, ok)
+	}
+}
+
+func TestS3StorageDeleteEmptyPrefix(t *testing.T) {
+	ss, stub, _ := newTestS3Storage(t, "")
+	if _, err := ss.Put(context.Background(), "avatar/u1/a.png", strings.NewReader("image"), 5, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	// 空前缀会删除整个 bucket 的对象，必须拒绝
+	for _, prefix := range []string{"", "/"} {
+		if err := ss.DeletePrefix(context.Background(), prefix); err == nil {
+			t.Fatalf("DeletePrefix(%q) should fail", prefix)
+		}
+	}
+	if _, ok := stub.object("avatar/u1/a.png"); !ok {
+		t.Fatal("objects should be kept")
+	}
+}
+
+func TestNewS3StorageConfig(t *testing.T) {
+	for _, conf := range []config.S3Storage{
+		{Bucket: testBucket},
+		{Endpoint: "127.0.0.1:9000"},
+	} {
+		if _, err := NewS3Storage(conf); err == nil {
+			t.Errorf("NewS3Storage(%+v) should fail", conf)
+		}
+	}
+}
@@ -67,6 +67,12 @@ func (m *Map[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
 	return
 }
 
+// CompareAndDelete 仅当 key 当前的值等于 old 时才删除，返回是否删除成功
+// 注意 V 的实际类型必须是可比较的，否则会 panic
+func (m *Map[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	return m.m.CompareAndDelete(key, old)
+}
+
 // Delete 删除键值对
 func (m *Map[K, V]) Delete(key K) {
 	m.m.Delete(key)
@@ -0,0 +1,26 @@
+package testx
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// Set 在测试期间把 *p 设置为 v，测试结束后恢复原值，用于临时修改 config.Conf 等全局配置
+func Set[T any](t testing.TB, p *T, v T) {
+	t.Helper()
+	old := *p
+	*p = v
+	t.Cleanup(func() { *p = old })
+}
+
+// Redis 启动一个 miniredis 并返回连接到它的客户端，测试结束时一并关闭
+// miniredis 中的过期时间不会自动流逝，需要通过 FastForward 推进
+func Redis(t testing.TB) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return mr, rdb
+}
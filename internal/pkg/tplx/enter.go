@@ -0,0 +1,189 @@
+package tplx
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"nurture/internal/pkg/i18nx"
+	"os"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// 邮件模板目录结构：
+//
+//	layout.html          公共 HTML 布局，定义 "layout" 并通过 {{template "html" .}} 引用正文
+//	<locale>/<name>.tmpl 某种邮件在某种语言下的模板，定义 "subject"、"html"，可选定义 "text"
+//
+// 配置了模板目录时，目录中的同名文件覆盖内置模板，新增的文件即新增一种邮件类型
+//
+//go:embed templates
+var embedFS embed.FS
+
+const (
+	layoutFile = "layout.html"
+	tmplExt    = ".tmpl"
+)
+
+var ErrTemplateNotExist = errors.New("email template does not exist")
+
+// Message 渲染后的邮件内容，Text 为空表示模板没有提供纯文本版本
+type Message struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Template 一种邮件在一种语言下的模板
+type Template struct {
+	subject *texttemplate.Template
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+}
+
+// Registry 按语言和邮件类型索引的模板集合
+type Registry struct {
+	templates map[string]map[string]*Template // locale -> name -> template
+}
+
+// InitTemplates 加载内置模板并用 dir 中的模板覆盖，dir 为空时只使用内置模板，模板错误时直接 panic
+func InitTemplates(dir string) *Registry {
+	r, err := NewRegistry(dir)
+	if err != nil {
+		panic(fmt.Sprintf("load email templates error: %v", err))
+	}
+	return r
+}
+
+func NewRegistry(dir string) (*Registry, error) {
+	sub, err := fs.Sub(embedFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+	files, err := readFiles(sub)
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		overrides, err := readFiles(os.DirFS(dir))
+		if err != nil {
+			return nil, fmt.Errorf("read template dir %s: %w", dir, err)
+		}
+		for name, content := range overrides {
+			files[name] = content
+		}
+	}
+	layout, ok := files[layoutFile]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing", layoutFile)
+	}
+	r := &Registry{templates: map[string]map[string]*Template{}}
+	for file, content := range files {
+		locale, name, ok := splitName(file)
+		if !ok {
+			continue
+		}
+		t, err := parse(file, layout, content)
+		if err != nil {
+			return nil, err
+		}
+		if r.templates[locale] == nil {
+			r.templates[locale] = map[string]*Template{}
+		}
+		r.templates[locale][name] = t
+	}
+	return r, nil
+}
+
+// Render 渲染 name 类型的邮件，locale 下没有该模板时回退到默认语言
+func (r *Registry) Render(locale, name string, data any) (Message, error) {
+	t, ok := r.templates[locale][name]
+	if !ok {
+		t, ok = r.templates[i18nx.DefaultLocale][name]
+	}
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrTemplateNotExist, name)
+	}
+	var msg Message
+	var buf bytes.Buffer
+	if err := t.subject.Execute(&buf, data); err != nil {
+		return Message{}, err
+	}
+	// 邮件主题不能换行
+	msg.Subject = strings.Join(strings.Fields(buf.String()), " ")
+	buf.Reset()
+	if err := t.html.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return Message{}, err
+	}
+	msg.HTML = buf.String()
+	if t.text != nil {
+		buf.Reset()
+		if err := t.text.Execute(&buf, data); err != nil {
+			return Message{}, err
+		}
+		msg.Text = strings.TrimSpace(buf.String())
+	}
+	return msg, nil
+}
+
+// readFiles 读取 layout.html 和 <locale>/<name>.tmpl
+func readFiles(fsys fs.FS) (map[string]string, error) {
+	files := map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (p != layoutFile && path.Ext(p) != tmplExt) {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		files[p] = string(data)
+		return nil
+	})
+	return files, err
+}
+
+// splitName 把 "en-US/login_code.tmpl" 拆分为语言和邮件类型
+func splitName(file string) (locale, name string, ok bool) {
+	dir, base := path.Split(file)
+	locale = strings.TrimSuffix(dir, "/")
+	if locale == "" || strings.Contains(locale, "/") || path.Ext(base) != tmplExt {
+		return "", "", false
+	}
+	if locale, ok = i18nx.Normalize(locale); !ok {
+		return "", "", false
+	}
+	return locale, strings.TrimSuffix(base, tmplExt), true
+}
+
+// parse 同一个模板文件分别以 text/template 和 html/template 解析：
+// "subject" 和 "text" 不做 HTML 转义，"html" 套用公共布局并按 HTML 上下文转义
+func parse(file, layout, content string) (*Template, error) {
+	textSet, err := texttemplate.New(file).Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	t := &Template{subject: textSet.Lookup("subject"), text: textSet.Lookup("text")}
+	if t.subject == nil {
+		return nil, fmt.Errorf("%s: subject is not defined", file)
+	}
+	htmlSet, err := htmltemplate.New(layoutFile).Parse(layout)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = htmlSet.New(file).Parse(content); err != nil {
+		return nil, err
+	}
+	if htmlSet.Lookup("html") == nil {
+		return nil, fmt.Errorf("%s: html is not defined", file)
+	}
+	t.html = htmlSet
+	return t, nil
+}
@@ -0,0 +1,79 @@
+package tracex
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	HeaderRequestID   = "X-Request-ID"
+	HeaderTraceparent = "traceparent"
+	// maxRequestIDLen 调用方传入的请求ID超过该长度时丢弃，重新生成
+	maxRequestIDLen = 128
+)
+
+type requestIDKey struct{}
+
+// WithRequestID 把请求ID写入 ctx
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID 返回 ctx 中的请求ID，不存在时返回空字符串
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// NewRequestID 生成与 W3C trace-id 格式相同的请求ID（32位小写十六进制）
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ValidRequestID 判断调用方传入的请求ID是否可以直接使用，只允许可打印的 ASCII 字符，避免日志注入
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTraceparent 解析 W3C traceparent 请求头（version-traceid-parentid-flags），返回其中的 trace-id
+func ParseTraceparent(traceparent string) (traceID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", false
+	}
+	// version 00 必须正好4段，更高的版本允许在末尾追加字段
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", false
+	}
+	if !isHex(parts[0]) || !isHex(parts[1]) || len(parts[1]) != 32 || !isHex(parts[2]) || len(parts[2]) != 16 || !isHex(parts[3]) || len(parts[3]) != 2 {
+		return "", false
+	}
+	// 全零的 trace-id 和 parent-id 是无效值
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// isHex 判断是否为小写十六进制字符串
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return s != ""
+}
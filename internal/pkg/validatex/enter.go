@@ -0,0 +1,197 @@
+package validatex
+
+import (
+	"errors"
+	"fmt"
+	"nurture/internal/config"
+	"nurture/internal/pkg/i18nx"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// 密码策略未配置时的默认长度限制
+const (
+	defaultPasswordMin = 8
+	defaultPasswordMax = 64
+)
+
+// 账号只允许字母、数字和下划线，长度 4-20（与 "user".account 列宽一致）
+var accountRegexp = regexp.MustCompile(`^[A-Za-z0-9_]{4,20}$`)
+
+var uni *ut.UniversalTranslator
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+}
+
+// InitValidator 为 gin 的校验器注册自定义规则和中英文错误信息，需要在注册路由之前调用
+func InitValidator() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		panic("gin validator engine is not go-playground/validator")
+	}
+	// 错误信息中使用 json/uri 中的字段名，而不是 Go 结构体的字段名
+	// json:"-" 的字段可能来自路径参数，继续查找 uri 标签
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "uri", "form"} {
+			name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return f.Name
+	})
+	must(v.RegisterValidation("account", validateAccount))
+	must(v.RegisterValidation("password", validatePassword))
+	must(v.RegisterValidation("locale", validateLocale))
+
+	uni = ut.New(zh.New(), zh.New(), en.New())
+	zhTrans, _ := uni.GetTranslator("zh")
+	enTrans, _ := uni.GetTranslator("en")
+	must(zh_translations.RegisterDefaultTranslations(v, zhTrans))
+	must(en_translations.RegisterDefaultTranslations(v, enTrans))
+	registerTranslation(v, zhTrans, "account", "{0}只能包含字母、数字和下划线，长度为4到20个字符", nil)
+	registerTranslation(v, enTrans, "account", "{0} must be 4-20 characters of letters, digits or underscores", nil)
+	registerTranslation(v, zhTrans, "locale", "{0}必须是支持的语言：{1}", localeParams)
+	registerTranslation(v, enTrans, "locale", "{0} must be one of the supported languages: {1}", localeParams)
+	registerTranslation(v, zhTrans, "password", "{0}长度必须在{1}到{2}个字符之间{3}", passwordParams("，且需包含", "、", map[string]string{
+		"upper": "大写字母", "lower": "小写字母", "digit": "数字", "symbol": "特殊符号",
+	}))
+	registerTranslation(v, enTrans, "password", "{0} must be {1}-{2} characters long{3}", passwordParams(" and contain ", ", ", map[string]string{
+		"upper": "an uppercase letter", "lower": "a lowercase letter", "digit": "a digit", "symbol": "a symbol",
+	}))
+}
+
+// Translate 把校验错误转换为 locale 语言的字段级错误列表，找不到对应语言时使用中文
+// 不是校验错误（例如 JSON 格式错误）时返回 nil
+func Translate(err error, locale string) []FieldError {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	// universal-translator 按主语言注册，例如 en-US -> en
+	trans, _ := uni.FindTranslator(strings.ToLower(strings.SplitN(locale, "-", 2)[0]))
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, FieldError{
+			Field:   fe.Field(),
+			Tag:     fe.Tag(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return fields
+}
+
+func validateAccount(fl validator.FieldLevel) bool {
+	return accountRegexp.MatchString(fl.Field().String())
+}
+
+func validateLocale(fl validator.FieldLevel) bool {
+	_, ok := i18nx.Normalize(fl.Field().String())
+	return ok
+}
+
+func localeParams() []string {
+	return []string{strings.Join(i18nx.Supported, ", ")}
+}
+
+// validatePassword 按配置的密码策略校验密码强度
+func validatePassword(fl validator.FieldLevel) bool {
+	p := config.Conf.Auth.PasswordPolicy
+	password := fl.Field().String()
+	minLen, maxLen := passwordLength(p)
+	if n := utf8.RuneCountInString(password); n < minLen || n > maxLen {
+		return false
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	return (!p.RequireUpper || upper) &&
+		(!p.RequireLower || lower) &&
+		(!p.RequireDigit || digit) &&
+		(!p.RequireSymbol || symbol)
+}
+
+func passwordLength(p config.PasswordPolicy) (int, int) {
+	minLen, maxLen := p.MinLength, p.MaxLength
+	if minLen <= 0 {
+		minLen = defaultPasswordMin
+	}
+	if maxLen <= 0 {
+		maxLen = defaultPasswordMax
+	}
+	return minLen, maxLen
+}
+
+// passwordParams 根据当前的密码策略生成错误信息中的长度和字符要求
+func passwordParams(prefix, sep string, names map[string]string) func() []string {
+	return func() []string {
+		p := config.Conf.Auth.PasswordPolicy
+		minLen, maxLen := passwordLength(p)
+		var required []string
+		if p.RequireUpper {
+			required = append(required, names["upper"])
+		}
+		if p.RequireLower {
+			required = append(required, names["lower"])
+		}
+		if p.RequireDigit {
+			required = append(required, names["digit"])
+		}
+		if p.RequireSymbol {
+			required = append(required, names["symbol"])
+		}
+		var rule string
+		if len(required) > 0 {
+			rule = prefix + strings.Join(required, sep)
+		}
+		return []string{fmt.Sprint(minLen), fmt.Sprint(maxLen), rule}
+	}
+}
+
+// registerTranslation 注册自定义规则的错误信息，params 用于生成 {1} 之后的参数
+func registerTranslation(v *validator.Validate, trans ut.Translator, tag, text string, params func() []string) {
+	must(v.RegisterTranslation(tag, trans, func(ut ut.Translator) error {
+		return ut.Add(tag, text, true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		args := []string{fe.Field()}
+		if params != nil {
+			args = append(args, params()...)
+		}
+		msg, err := ut.T(tag, args...)
+		if err != nil {
+			return fe.Error()
+		}
+		return msg
+	}))
+}
+
+func must(err error) {
+	if err != nil {
+		panic(fmt.Sprintf("init validator error: %v", err))
+	}
+}
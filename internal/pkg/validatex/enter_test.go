@@ -0,0 +1,162 @@
+package validatex
+
+import (
+	"nurture/internal/config"
+	"nurture/internal/pkg/testx"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestMain(m *testing.M) {
+	InitValidator()
+	os.Exit(m.Run())
+}
+
+type testReq struct {
+	Account  string `json:"account" binding:"omitempty,account"`
+	Password string `json:"password" binding:"omitempty,password"`
+	Locale   string `json:"locale" binding:"omitempty,locale"`
+	Email    string `json:"email" binding:"omitempty,email"`
+	ID       string `json:"-" uri:"id" binding:"omitempty,uuid"`
+}
+
+func TestAccount(t *testing.T) {
+	tests := []struct {
+		account string
+		valid   bool
+	}{
+		{account: "user_01", valid: true},
+		{account: "abcd", valid: true},
+		{account: strings.Repeat("a", 20), valid: true},
+		{account: "abc"},
+		{account: strings.Repeat("a", 21)},
+		{account: "user-01"},
+		{account: "用户名称"},
+		{account: "user 01"},
+	}
+	for _, tt := range tests {
+		err := binding.Validator.ValidateStruct(testReq{Account: tt.account})
+		if (err == nil) != tt.valid {
+			t.Errorf("account %q: err=%v, want valid=%t", tt.account, err, tt.valid)
+		}
+	}
+}
+
+func TestPassword(t *testing.T) {
+	t.Run("default policy", func(t *testing.T) {
+		testx.Set(t, &config.Conf.Auth.PasswordPolicy, config.PasswordPolicy{})
+		for password, valid := range map[string]bool{
+			"12345678":              true,
+			"1234567":               false,
+			strings.Repeat("a", 64): true,
+			strings.Repeat("a", 65): false,
+			// 长度按字符计算
+			"密码密码密码密码": true,
+		} {
+			err := binding.Validator.ValidateStruct(testReq{Password: password})
+			if (err == nil) != valid {
+				t.Errorf("password %q: err=%v, want valid=%t", password, err, valid)
+			}
+		}
+	})
+	t.Run("strict policy", func(t *testing.T) {
+		testx.Set(t, &config.Conf.Auth.PasswordPolicy, config.PasswordPolicy{
+			MinLength:     10,
+			MaxLength:     20,
+			RequireUpper:  true,
+			RequireLower:  true,
+			RequireDigit:  true,
+			RequireSymbol: true,
+		})
+		for password, valid := range map[string]bool{
+			"Abcdefgh1!":            true,
+			"Abcdef1!":              false,
+			"abcdefgh1!":            false,
+			"ABCDEFGH1!":            false,
+			"Abcdefghi!":            false,
+			"Abcdefghi1":            false,
+			"Abcdefgh1!xxxxxxxxxxx": false,
+		} {
+			err := binding.Validator.ValidateStruct(testReq{Password: password})
+			if (err == nil) != valid {
+				t.Errorf("password %q: err=%v, want valid=%t", password, err, valid)
+			}
+		}
+	})
+}
+
+func TestLocale(t *testing.T) {
+	for locale, valid := range map[string]bool{
+		"zh-CN": true,
+		"en-US": true,
+		"en-us": true,
+		"fr-FR": false,
+		"xx":    false,
+	} {
+		err := binding.Validator.ValidateStruct(testReq{Locale: locale})
+		if (err == nil) != valid {
+			t.Errorf("locale %q: err=%v, want valid=%t", locale, err, valid)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	testx.Set(t, &config.Conf.Auth.PasswordPolicy, config.PasswordPolicy{MinLength: 10, MaxLength: 20, RequireDigit: true})
+	err := binding.Validator.ValidateStruct(testReq{
+		Account:  "a",
+		Password: "short",
+		Email:    "not-an-email",
+		ID:       "1",
+	})
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	tests := []struct {
+		locale string
+		want   map[string]string // 字段 -> 错误信息中应包含的内容
+	}{
+		{locale: "zh-CN", want: map[string]string{
+			"account":  "只能包含字母、数字和下划线",
+			"password": "长度必须在10到20个字符之间，且需包含数字",
+			"email":    "email",
+			"id":       "id",
+		}},
+		{locale: "en-US", want: map[string]string{
+			"account":  "must be 4-20 characters",
+			"password": "must be 10-20 characters long and contain a digit",
+			"email":    "email",
+			"id":       "id",
+		}},
+		// 不支持的语言使用中文
+		{locale: "fr-FR", want: map[string]string{
+			"account": "只能包含字母、数字和下划线",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			fields := Translate(err, tt.locale)
+			got := make(map[string]FieldError, len(fields))
+			for _, f := range fields {
+				got[f.Field] = f
+			}
+			for field, want := range tt.want {
+				f, ok := got[field]
+				if !ok {
+					t.Fatalf("missing error for field %q in %+v", field, fields)
+				}
+				if !strings.Contains(f.Message, want) {
+					t.Errorf("field %q: message %q should contain %q", field, f.Message, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTranslateNonValidationError(t *testing.T) {
+	if fields := Translate(os.ErrNotExist, "zh-CN"); fields != nil {
+		t.Fatalf("got %v, want nil", fields)
+	}
+}
@@ -46,7 +46,8 @@ func InitZap() *zap.SugaredLogger {
 		cores = append(cores, consoleInfoCore)
 
 	}
-	logger = zap.New(zapcore.NewTee(cores...), zap.AddCaller(), zap.AddCallerSkip(0))
+	// 所有输出都经过脱敏，带有 log:"redact" 标签的字段不会写入日志
+	logger = zap.New(newRedactCore(zapcore.NewTee(cores...)), zap.AddCaller(), zap.AddCallerSkip(0))
 	defer logger.Sync()
 	return logger.Sugar()
 }
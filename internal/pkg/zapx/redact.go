@@ -0,0 +1,237 @@
+package zapx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactTag 结构体字段带有 log:"redact" 标签时，写入日志的值被替换为 RedactMask
+const (
+	RedactTag  = "redact"
+	RedactMask = "***"
+)
+
+// LogValuer 类型实现该接口时，写入日志的是 LogValue 的返回值，优先于 log 标签
+type LogValuer interface {
+	LogValue() any
+}
+
+// redactCore 在写入日志前处理以反射方式编码的字段，例如 zap.Any 和 SugaredLogger.Infow 的键值对
+// 注意 Info(v)、Infof("%v", v) 等会先把 v 格式化为字符串，不经过字段处理，敏感数据需要作为字段记录
+type redactCore struct {
+	zapcore.Core
+}
+
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+// redactFields 只在确实需要处理时复制字段，避免影响调用方的切片
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		if f.Type != zapcore.ReflectType || f.Interface == nil {
+			continue
+		}
+		if !needRedact(reflect.TypeOf(f.Interface)) {
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i] = zap.Reflect(f.Key, Redact(f.Interface))
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// Redact 返回 v 用于写入日志的副本：log:"redact" 字段被遮盖，结构体转换为以 json 标签为键的 map
+// 不包含需要遮盖的字段时原样返回
+func Redact(v any) any {
+	if v == nil {
+		return nil
+	}
+	return redactValue(reflect.ValueOf(v))
+}
+
+func redactValue(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() {
+		if lv, ok := v.Interface().(LogValuer); ok {
+			return lv.LogValue()
+		}
+	}
+	if !needRedact(v.Type()) {
+		if v.CanInterface() {
+			return v.Interface()
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]any, v.NumField())
+		redactStruct(v, m)
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = redactValue(v.Index(i))
+		}
+		return list
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[toKey(iter.Key())] = redactValue(iter.Value())
+		}
+		return m
+	default:
+		return v.Interface()
+	}
+}
+
+// redactStruct 按 encoding/json 的规则展开字段，匿名嵌入且没有 json 名称的结构体平铺到外层
+func redactStruct(v reflect.Value, m map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, skip := jsonName(sf)
+		if skip {
+			continue
+		}
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv, ft = fv.Elem(), ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				redactStruct(fv, m)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if hasRedactTag(sf) {
+			if fv.IsZero() {
+				m[name] = fv.Interface()
+			} else {
+				m[name] = RedactMask
+			}
+			continue
+		}
+		m[name] = redactValue(fv)
+	}
+}
+
+func jsonName(sf reflect.StructField) (name string, skip bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ = strings.Cut(tag, ",")
+	return name, false
+}
+
+func hasRedactTag(sf reflect.StructField) bool {
+	for _, opt := range strings.Split(sf.Tag.Get("log"), ",") {
+		if opt == RedactTag {
+			return true
+		}
+	}
+	return false
+}
+
+func toKey(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// needRedactCache 缓存类型中是否包含需要遮盖的字段或实现了 LogValuer 的类型
+var needRedactCache sync.Map
+
+var logValuerType = reflect.TypeFor[LogValuer]()
+
+func needRedact(t reflect.Type) bool {
+	if cached, ok := needRedactCache.Load(t); ok {
+		return cached.(bool)
+	}
+	need := typeNeedRedact(t, map[reflect.Type]bool{})
+	needRedactCache.Store(t, need)
+	return need
+}
+
+func typeNeedRedact(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if t.Implements(logValuerType) {
+		return true
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return typeNeedRedact(t.Elem(), visiting)
+	case reflect.Map:
+		return typeNeedRedact(t.Elem(), visiting)
+	case reflect.Interface:
+		// 接口的动态类型在运行时才知道，统一展开
+		return t.NumMethod() == 0
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if _, skip := jsonName(sf); skip {
+				continue
+			}
+			if hasRedactTag(sf) || typeNeedRedact(sf.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package zapx
+
+import (
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type loginReq struct {
+	Account  string `json:"account"`
+	Password string `json:"password" log:"redact"`
+	Code     string `json:"code" log:"redact"`
+	Internal string `json:"-" log:"redact"`
+}
+
+type Base struct {
+	Token string `json:"token" log:"redact"`
+}
+
+type embedReq struct {
+	Base
+	Name string `json:"name"`
+}
+
+type nestedReq struct {
+	User  *loginReq           `json:"user"`
+	List  []loginReq          `json:"list"`
+	Extra map[string]any      `json:"extra"`
+	ByKey map[string]loginReq `json:"by_key"`
+}
+
+type secret string
+
+func (secret) LogValue() any { return RedactMask }
+
+type node struct {
+	Name string `json:"name"`
+	Next *node  `json:"next"`
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want any
+	}{
+		{
+			name: "struct",
+			in:   loginReq{Account: "alice", Password: "p@ss", Code: "123456", Internal: "x"},
+			want: map[string]any{"account": "alice", "password": RedactMask, "code": RedactMask},
+		},
+		{
+			// 零值不遮盖，可以看出字段是否为空
+			name: "zero value",
+			in:   loginReq{Account: "alice"},
+			want: map[string]any{"account": "alice", "password": "", "code": ""},
+		},
+		{
+			name: "pointer",
+			in:   &loginReq{Password: "p@ss"},
+			want: map[string]any{"account": "", "password": RedactMask, "code": ""},
+		},
+		{
+			name: "embedded",
+			in:   embedReq{Base: Base{Token: "t"}, Name: "n"},
+			want: map[string]any{"token": RedactMask, "name": "n"},
+		},
+		{
+			name: "nested",
+			in: nestedReq{
+				User:  &loginReq{Password: "p"},
+				List:  []loginReq{{Code: "1"}},
+				Extra: map[string]any{"req": loginReq{Password: "p"}, "n": 1},
+				ByKey: map[string]loginReq{"k": {Password: "p"}},
+			},
+			want: map[string]any{
+				"user":   map[string]any{"account": "", "password": RedactMask, "code": ""},
+				"list":   []any{map[string]any{"account": "", "password": "", "code": RedactMask}},
+				"extra":  map[string]any{"req": map[string]any{"account": "", "password": RedactMask, "code": ""}, "n": 1},
+				"by_key": map[string]any{"k": map[string]any{"account": "", "password": RedactMask, "code": ""}},
+			},
+		},
+		{
+			name: "log valuer",
+			in:   secret("s"),
+			want: RedactMask,
+		},
+		{
+			// 不包含需要遮盖的字段时原样返回
+			name: "untouched",
+			in:   node{Name: "a", Next: &node{Name: "b"}},
+			want: node{Name: "a", Next: &node{Name: "b"}},
+		},
+		{
+			name: "nil",
+			in:   nil,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Redact() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactCore(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(newRedactCore(core)).Sugar()
+	req := loginReq{Account: "alice", Password: "p@ss"}
+	fields := []any{"req", req, "count", 1}
+
+	logger.Infow("login", fields...)
+	logger.With("req", &req).Info("with")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	want := map[string]any{"account": "alice", "password": RedactMask, "code": ""}
+	for _, entry := range entries {
+		got, ok := entry.ContextMap()["req"]
+		if !ok {
+			t.Fatalf("%s: missing field req", entry.Message)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: req = %#v, want %#v", entry.Message, got, want)
+		}
+	}
+	if entries[0].ContextMap()["count"] != int64(1) {
+		t.Fatalf("count = %#v, want 1", entries[0].ContextMap()["count"])
+	}
+	// 调用方的数据不被修改
+	if req.Password != "p@ss" || fields[1].(loginReq).Password != "p@ss" {
+		t.Fatal("Redact should not modify the logged value")
+	}
+}
@@ -0,0 +1,188 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/global"
+	"nurture/internal/repo/account"
+	"nurture/internal/repo/session"
+	"nurture/internal/repo/token"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type IAccountRepo interface {
+	SoftDelete(ctx context.Context, userID string) (int64, error)
+	Restore(ctx context.Context, userID string) error
+	Purge(ctx context.Context, before int64, batchSize int32) ([]string, error)
+	Export(ctx context.Context, userID string) (AccountData, error)
+}
+type AccountRepo struct {
+	accountDao *account.Queries
+}
+
+func NewAccountRepo() *AccountRepo {
+	return &AccountRepo{
+		accountDao: account.New(global.DB),
+	}
+}
+
+var _ IAccountRepo = (*AccountRepo)(nil)
+
+// AccountData 用户在各个表中保存的数据，令牌摘要等凭据不包含在内
+type AccountData struct {
+	User          account.User
+	Sessions      []account.UserSession
+	RefreshTokens []account.ListRefreshTokensByUserIDRow
+	EmailChanges  []account.ListEmailChangesByUserIDRow
+	Emails        []account.ListOutboxEmailsByRecipientsRow
+}
+
+// SoftDelete 标记账号为已注销并注销所有会话和刷新令牌，返回注销时间
+// 账号已经处于注销状态时返回 ErrUserNotExist
+func (ar *AccountRepo) SoftDelete(ctx context.Context, userID string) (int64, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return 0, ErrUserNotExist
+	}
+	tx, err := global.DB.Begin(ctx)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return 0, ErrDefault
+	}
+	defer tx.Rollback(ctx)
+	now := time.Now().UnixMilli()
+	n, err := ar.accountDao.WithTx(tx).SoftDeleteUser(ctx, account.SoftDeleteUserParams{
+		UserID:    userUUID,
+		DeletedAt: now,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return 0, ErrDefault
+	}
+	if n == 0 {
+		return 0, ErrUserNotExist
+	}
+	if _, err := session.New(tx).RevokeAllSessions(ctx, userUUID); err != nil {
+		global.Logger(ctx).Error(err)
+		return 0, ErrDefault
+	}
+	if _, err := token.New(tx).RevokeAllRefreshTokens(ctx, userUUID); err != nil {
+		global.Logger(ctx).Error(err)
+		return 0, ErrDefault
+	}
+	if err := tx.Commit(ctx); err != nil {
+		global.Logger(ctx).Error(err)
+		return 0, ErrDefault
+	}
+	return now, nil
+}
+
+// Restore 取消注销，账号不处于注销状态时什么也不做
+func (ar *AccountRepo) Restore(ctx context.Context, userID string) error {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return ErrUserNotExist
+	}
+	_, err := ar.accountDao.RestoreUser(ctx, account.RestoreUserParams{
+		UserID: userUUID,
+		Utime:  time.Now().UnixMilli(),
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+// Purge 彻底删除最多 batchSize 个在 before 之前注销的账号，返回被删除的用户ID
+// 相关的会话、刷新令牌、邮箱修改记录和发往其邮箱的邮件一并删除
+func (ar *AccountRepo) Purge(ctx context.Context, before int64, batchSize int32) ([]string, error) {
+	userIDs, err := ar.purge(ctx, before, batchSize)
+	if err != nil {
+		// 停止时取消的查询不算错误
+		if ctx.Err() == nil {
+			global.Logger(ctx).Error(err)
+		}
+		return nil, ErrDefault
+	}
+	return userIDs, nil
+}
+
+func (ar *AccountRepo) purge(ctx context.Context, before int64, batchSize int32) ([]string, error) {
+	tx, err := global.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	dao := ar.accountDao.WithTx(tx)
+	userUUIDs, err := dao.ListPurgeableUsers(ctx, account.ListPurgeableUsersParams{
+		Before:    before,
+		BatchSize: batchSize,
+	})
+	if err != nil || len(userUUIDs) == 0 {
+		return nil, err
+	}
+	if _, err := dao.DeleteOutboxEmailsOfUsers(ctx, userUUIDs); err != nil {
+		return nil, err
+	}
+	if _, err := dao.DeleteUsers(ctx, userUUIDs); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	userIDs := make([]string, len(userUUIDs))
+	for i, u := range userUUIDs {
+		userIDs[i] = u.String()
+	}
+	return userIDs, nil
+}
+
+// Export 在同一个只读快照中读取用户的全部数据，保证导出的各部分相互一致
+func (ar *AccountRepo) Export(ctx context.Context, userID string) (AccountData, error) {
+	var data AccountData
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return data, ErrUserNotExist
+	}
+	tx, err := global.DB.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return data, ErrDefault
+	}
+	defer tx.Rollback(ctx)
+	dao := ar.accountDao.WithTx(tx)
+	data.User, err = dao.GetUserByUserID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return data, ErrUserNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return data, ErrDefault
+	}
+	if data.Sessions, err = dao.ListSessionsByUserID(ctx, userUUID); err != nil {
+		global.Logger(ctx).Error(err)
+		return data, ErrDefault
+	}
+	if data.RefreshTokens, err = dao.ListRefreshTokensByUserID(ctx, userUUID); err != nil {
+		global.Logger(ctx).Error(err)
+		return data, ErrDefault
+	}
+	if data.EmailChanges, err = dao.ListEmailChangesByUserID(ctx, userUUID); err != nil {
+		global.Logger(ctx).Error(err)
+		return data, ErrDefault
+	}
+	// 发往当前邮箱和历史邮箱的邮件
+	recipients := []string{data.User.Email}
+	for _, c := range data.EmailChanges {
+		recipients = append(recipients, c.OldEmail, c.NewEmail)
+	}
+	if data.Emails, err = dao.ListOutboxEmailsByRecipients(ctx, recipients); err != nil {
+		global.Logger(ctx).Error(err)
+		return data, ErrDefault
+	}
+	return data, nil
+}
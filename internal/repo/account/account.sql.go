@@ -0,0 +1,314 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: account.sql
+
+package account
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteOutboxEmailsOfUsers = `-- name: DeleteOutboxEmailsOfUsers :execrows
+DELETE FROM email_outbox
+WHERE recipient IN (
+  SELECT email FROM "user" WHERE user_id = ANY($1::uuid[])
+  UNION
+  SELECT old_email FROM email_change WHERE user_id = ANY($1::uuid[])
+  UNION
+  SELECT new_email FROM email_change WHERE user_id = ANY($1::uuid[])
+)
+AND recipient NOT IN (
+  SELECT email FROM "user" WHERE user_id <> ALL($1::uuid[])
+)
+`
+
+// 删除发往这些用户当前及历史邮箱的邮件，已被其他用户使用的邮箱除外
+func (q *Queries) DeleteOutboxEmailsOfUsers(ctx context.Context, userIds []pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOutboxEmailsOfUsers, userIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUsers = `-- name: DeleteUsers :execrows
+DELETE FROM "user"
+WHERE user_id = ANY($1::uuid[])
+`
+
+// 会话、刷新令牌和邮箱修改记录通过外键级联删除
+func (q *Queries) DeleteUsers(ctx context.Context, userIds []pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUsers, userIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getUserByUserID = `-- name: GetUserByUserID :one
+SELECT id, user_id, ctime, utime, account, password, email, username, avatar, role, token_version, locale, deleted_at, disabled, must_reset_password FROM "user"
+WHERE user_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByUserID(ctx context.Context, userID pgtype.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByUserID, userID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Ctime,
+		&i.Utime,
+		&i.Account,
+		&i.Password,
+		&i.Email,
+		&i.Username,
+		&i.Avatar,
+		&i.Role,
+		&i.TokenVersion,
+		&i.Locale,
+		&i.DeletedAt,
+		&i.Disabled,
+		&i.MustResetPassword,
+	)
+	return i, err
+}
+
+const listEmailChangesByUserID = `-- name: ListEmailChangesByUserID :many
+SELECT old_email, new_email, ctime, expire_time, reverted FROM email_change
+WHERE user_id = $1
+ORDER BY ctime
+`
+
+type ListEmailChangesByUserIDRow struct {
+	OldEmail   string
+	NewEmail   string
+	Ctime      int64
+	ExpireTime int64
+	Reverted   bool
+}
+
+func (q *Queries) ListEmailChangesByUserID(ctx context.Context, userID pgtype.UUID) ([]ListEmailChangesByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, listEmailChangesByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListEmailChangesByUserIDRow
+	for rows.Next() {
+		var i ListEmailChangesByUserIDRow
+		if err := rows.Scan(
+			&i.OldEmail,
+			&i.NewEmail,
+			&i.Ctime,
+			&i.ExpireTime,
+			&i.Reverted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOutboxEmailsByRecipients = `-- name: ListOutboxEmailsByRecipients :many
+SELECT message_id, recipient, template, locale, subject, status, attempts, ctime, utime FROM email_outbox
+WHERE recipient = ANY($1::varchar[])
+ORDER BY ctime
+`
+
+type ListOutboxEmailsByRecipientsRow struct {
+	MessageID pgtype.UUID
+	Recipient string
+	Template  string
+	Locale    string
+	Subject   string
+	Status    int16
+	Attempts  int32
+	Ctime     int64
+	Utime     int64
+}
+
+func (q *Queries) ListOutboxEmailsByRecipients(ctx context.Context, recipients []string) ([]ListOutboxEmailsByRecipientsRow, error) {
+	rows, err := q.db.Query(ctx, listOutboxEmailsByRecipients, recipients)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOutboxEmailsByRecipientsRow
+	for rows.Next() {
+		var i ListOutboxEmailsByRecipientsRow
+		if err := rows.Scan(
+			&i.MessageID,
+			&i.Recipient,
+			&i.Template,
+			&i.Locale,
+			&i.Subject,
+			&i.Status,
+			&i.Attempts,
+			&i.Ctime,
+			&i.Utime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPurgeableUsers = `-- name: ListPurgeableUsers :many
+SELECT user_id FROM "user"
+WHERE deleted_at > 0 AND deleted_at < $1
+ORDER BY deleted_at
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+type ListPurgeableUsersParams struct {
+	Before    int64
+	BatchSize int32
+}
+
+// 宽限期已过的注销账号，SKIP LOCKED 保证多个实例不会重复处理
+func (q *Queries) ListPurgeableUsers(ctx context.Context, arg ListPurgeableUsersParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listPurgeableUsers, arg.Before, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var user_id pgtype.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRefreshTokensByUserID = `-- name: ListRefreshTokensByUserID :many
+SELECT family_id, ctime, expire_time, used, revoked FROM refresh_token
+WHERE user_id = $1
+ORDER BY ctime
+`
+
+type ListRefreshTokensByUserIDRow struct {
+	FamilyID   pgtype.UUID
+	Ctime      int64
+	ExpireTime int64
+	Used       bool
+	Revoked    bool
+}
+
+func (q *Queries) ListRefreshTokensByUserID(ctx context.Context, userID pgtype.UUID) ([]ListRefreshTokensByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, listRefreshTokensByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRefreshTokensByUserIDRow
+	for rows.Next() {
+		var i ListRefreshTokensByUserIDRow
+		if err := rows.Scan(
+			&i.FamilyID,
+			&i.Ctime,
+			&i.ExpireTime,
+			&i.Used,
+			&i.Revoked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionsByUserID = `-- name: ListSessionsByUserID :many
+SELECT id, session_id, user_id, user_agent, ip, ctime, last_seen, expire_time, revoked FROM user_session
+WHERE user_id = $1
+ORDER BY ctime
+`
+
+func (q *Queries) ListSessionsByUserID(ctx context.Context, userID pgtype.UUID) ([]UserSession, error) {
+	rows, err := q.db.Query(ctx, listSessionsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserSession
+	for rows.Next() {
+		var i UserSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.UserID,
+			&i.UserAgent,
+			&i.Ip,
+			&i.Ctime,
+			&i.LastSeen,
+			&i.ExpireTime,
+			&i.Revoked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreUser = `-- name: RestoreUser :execrows
+UPDATE "user"
+SET deleted_at = 0, utime = $2
+WHERE user_id = $1 AND deleted_at > 0
+`
+
+type RestoreUserParams struct {
+	UserID pgtype.UUID
+	Utime  int64
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, arg RestoreUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreUser, arg.UserID, arg.Utime)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :execrows
+UPDATE "user"
+SET deleted_at = $2, token_version = token_version + 1, utime = $2
+WHERE user_id = $1 AND deleted_at = 0
+`
+
+type SoftDeleteUserParams struct {
+	UserID    pgtype.UUID
+	DeletedAt int64
+}
+
+// 标记注销并递增令牌版本，已签发的访问令牌立即失效
+func (q *Queries) SoftDeleteUser(ctx context.Context, arg SoftDeleteUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, softDeleteUser, arg.UserID, arg.DeletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/global"
+	"nurture/internal/repo/admin"
+	"nurture/internal/repo/session"
+	"nurture/internal/repo/token"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type IAdminRepo interface {
+	ListUsers(ctx context.Context, filter UserFilter, limit, offset int32) ([]admin.User, int64, error)
+	GetUser(ctx context.Context, userID string) (admin.User, error)
+	UpdateRole(ctx context.Context, userID string, role int16) (admin.User, error)
+	SetDisabled(ctx context.Context, userID string, disabled bool) (admin.User, error)
+	RequirePasswordReset(ctx context.Context, userID string) (admin.User, error)
+}
+type AdminRepo struct {
+	adminDao *admin.Queries
+}
+
+func NewAdminRepo() *AdminRepo {
+	return &AdminRepo{
+		adminDao: admin.New(global.DB),
+	}
+}
+
+var _ IAdminRepo = (*AdminRepo)(nil)
+
+// UserFilter 用户列表的筛选条件，为 nil 的条件不生效
+type UserFilter struct {
+	Keyword  *string
+	Role     *int16
+	Disabled *bool
+	Deleted  *bool // 是否处于注销宽限期
+}
+
+// ListUsers 按筛选条件分页查询用户，同时返回满足条件的总数
+func (ar *AdminRepo) ListUsers(ctx context.Context, filter UserFilter, limit, offset int32) ([]admin.User, int64, error) {
+	var keyword pgtype.Text
+	if filter.Keyword != nil {
+		keyword = pgtype.Text{String: *filter.Keyword, Valid: true}
+	}
+	var role pgtype.Int2
+	if filter.Role != nil {
+		role = pgtype.Int2{Int16: *filter.Role, Valid: true}
+	}
+	var disabled, deleted pgtype.Bool
+	if filter.Disabled != nil {
+		disabled = pgtype.Bool{Bool: *filter.Disabled, Valid: true}
+	}
+	if filter.Deleted != nil {
+		deleted = pgtype.Bool{Bool: *filter.Deleted, Valid: true}
+	}
+	total, err := ar.adminDao.CountUsers(ctx, admin.CountUsersParams{
+		Keyword:  keyword,
+		Role:     role,
+		Disabled: disabled,
+		Deleted:  deleted,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return nil, 0, ErrDefault
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+	users, err := ar.adminDao.ListUsers(ctx, admin.ListUsersParams{
+		Keyword:     keyword,
+		Role:        role,
+		Disabled:    disabled,
+		Deleted:     deleted,
+		LimitCount:  limit,
+		OffsetCount: offset,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return nil, 0, ErrDefault
+	}
+	return users, total, nil
+}
+
+func (ar *AdminRepo) GetUser(ctx context.Context, userID string) (admin.User, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return admin.User{}, ErrUserNotExist
+	}
+	u, err := ar.adminDao.GetUserByUserID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return admin.User{}, ErrUserNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return admin.User{}, ErrDefault
+	}
+	return u, nil
+}
+
+// UpdateRole 修改角色并递增令牌版本，会话保留，刷新令牌后按新角色签发访问令牌
+func (ar *AdminRepo) UpdateRole(ctx context.Context, userID string, role int16) (admin.User, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return admin.User{}, ErrUserNotExist
+	}
+	u, err := ar.adminDao.UpdateRoleByUserID(ctx, admin.UpdateRoleByUserIDParams{
+		UserID: userUUID,
+		Role:   role,
+		Utime:  time.Now().UnixMilli(),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return admin.User{}, ErrUserNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return admin.User{}, ErrDefault
+	}
+	return u, nil
+}
+
+// SetDisabled 禁用或启用账号，禁用时同时注销该用户的所有会话和刷新令牌
+func (ar *AdminRepo) SetDisabled(ctx context.Context, userID string, disabled bool) (admin.User, error) {
+	return ar.updateAndRevoke(ctx, userID, disabled, func(dao *admin.Queries, userUUID pgtype.UUID, now int64) (admin.User, error) {
+		return dao.SetDisabledByUserID(ctx, admin.SetDisabledByUserIDParams{
+			UserID:   userUUID,
+			Disabled: disabled,
+			Utime:    now,
+		})
+	})
+}
+
+// RequirePasswordReset 要求用户通过邮箱重置密码，同时注销该用户的所有会话和刷新令牌
+func (ar *AdminRepo) RequirePasswordReset(ctx context.Context, userID string) (admin.User, error) {
+	return ar.updateAndRevoke(ctx, userID, true, func(dao *admin.Queries, userUUID pgtype.UUID, now int64) (admin.User, error) {
+		return dao.RequirePasswordResetByUserID(ctx, admin.RequirePasswordResetByUserIDParams{
+			UserID: userUUID,
+			Utime:  now,
+		})
+	})
+}
+
+// updateAndRevoke 在同一个事务中修改用户，revoke 为 true 时注销该用户的所有会话和刷新令牌
+func (ar *AdminRepo) updateAndRevoke(ctx context.Context, userID string, revoke bool, update func(dao *admin.Queries, userUUID pgtype.UUID, now int64) (admin.User, error)) (admin.User, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return admin.User{}, ErrUserNotExist
+	}
+	tx, err := global.DB.Begin(ctx)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return admin.User{}, ErrDefault
+	}
+	defer tx.Rollback(ctx)
+	u, err := update(ar.adminDao.WithTx(tx), userUUID, time.Now().UnixMilli())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return admin.User{}, ErrUserNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return admin.User{}, ErrDefault
+	}
+	if revoke {
+		if _, err := session.New(tx).RevokeAllSessions(ctx, userUUID); err != nil {
+			global.Logger(ctx).Error(err)
+			return admin.User{}, ErrDefault
+		}
+		if _, err := token.New(tx).RevokeAllRefreshTokens(ctx, userUUID); err != nil {
+			global.Logger(ctx).Error(err)
+			return admin.User{}, ErrDefault
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		global.Logger(ctx).Error(err)
+		return admin.User{}, ErrDefault
+	}
+	return u, nil
+}
@@ -0,0 +1,244 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: admin.sql
+
+package admin
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countUsers = `-- name: CountUsers :one
+SELECT count(*) FROM "user"
+WHERE ($1::text IS NULL
+       OR strpos(lower(account), lower($1::text)) > 0
+       OR strpos(lower(email), lower($1::text)) > 0
+       OR strpos(lower(username), lower($1::text)) > 0)
+  AND ($2::smallint IS NULL OR role = $2::smallint)
+  AND ($3::boolean IS NULL OR disabled = $3::boolean)
+  AND ($4::boolean IS NULL OR (deleted_at > 0) = $4::boolean)
+`
+
+type CountUsersParams struct {
+	Keyword  pgtype.Text
+	Role     pgtype.Int2
+	Disabled pgtype.Bool
+	Deleted  pgtype.Bool
+}
+
+func (q *Queries) CountUsers(ctx context.Context, arg CountUsersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers,
+		arg.Keyword,
+		arg.Role,
+		arg.Disabled,
+		arg.Deleted,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getUserByUserID = `-- name: GetUserByUserID :one
+SELECT id, user_id, ctime, utime, account, password, email, username, avatar, role, token_version, locale, deleted_at, disabled, must_reset_password FROM "user"
+WHERE user_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByUserID(ctx context.Context, userID pgtype.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByUserID, userID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Ctime,
+		&i.Utime,
+		&i.Account,
+		&i.Password,
+		&i.Email,
+		&i.Username,
+		&i.Avatar,
+		&i.Role,
+		&i.TokenVersion,
+		&i.Locale,
+		&i.DeletedAt,
+		&i.Disabled,
+		&i.MustResetPassword,
+	)
+	return i, err
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, user_id, ctime, utime, account, password, email, username, avatar, role, token_version, locale, deleted_at, disabled, must_reset_password FROM "user"
+WHERE ($1::text IS NULL
+       OR strpos(lower(account), lower($1::text)) > 0
+       OR strpos(lower(email), lower($1::text)) > 0
+       OR strpos(lower(username), lower($1::text)) > 0)
+  AND ($2::smallint IS NULL OR role = $2::smallint)
+  AND ($3::boolean IS NULL OR disabled = $3::boolean)
+  AND ($4::boolean IS NULL OR (deleted_at > 0) = $4::boolean)
+ORDER BY id DESC
+LIMIT $6 OFFSET $5
+`
+
+type ListUsersParams struct {
+	Keyword     pgtype.Text
+	Role        pgtype.Int2
+	Disabled    pgtype.Bool
+	Deleted     pgtype.Bool
+	OffsetCount int32
+	LimitCount  int32
+}
+
+// 参数为 NULL 的筛选条件不生效，keyword 匹配账号、邮箱或用户名的子串
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.Keyword,
+		arg.Role,
+		arg.Disabled,
+		arg.Deleted,
+		arg.OffsetCount,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Ctime,
+			&i.Utime,
+			&i.Account,
+			&i.Password,
+			&i.Email,
+			&i.Username,
+			&i.Avatar,
+			&i.Role,
+			&i.TokenVersion,
+			&i.Locale,
+			&i.DeletedAt,
+			&i.Disabled,
+			&i.MustResetPassword,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const requirePasswordResetByUserID = `-- name: RequirePasswordResetByUserID :one
+UPDATE "user"
+SET must_reset_password = TRUE, token_version = token_version + 1, utime = $2
+WHERE user_id = $1
+RETURNING id, user_id, ctime, utime, account, password, email, username, avatar, role, token_version, locale, deleted_at, disabled, must_reset_password
+`
+
+type RequirePasswordResetByUserIDParams struct {
+	UserID pgtype.UUID
+	Utime  int64
+}
+
+func (q *Queries) RequirePasswordResetByUserID(ctx context.Context, arg RequirePasswordResetByUserIDParams) (User, error) {
+	row := q.db.QueryRow(ctx, requirePasswordResetByUserID, arg.UserID, arg.Utime)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Ctime,
+		&i.Utime,
+		&i.Account,
+		&i.Password,
+		&i.Email,
+		&i.Username,
+		&i.Avatar,
+		&i.Role,
+		&i.TokenVersion,
+		&i.Locale,
+		&i.DeletedAt,
+		&i.Disabled,
+		&i.MustResetPassword,
+	)
+	return i, err
+}
+
+const setDisabledByUserID = `-- name: SetDisabledByUserID :one
+UPDATE "user"
+SET disabled = $2, token_version = token_version + 1, utime = $3
+WHERE user_id = $1
+RETURNING id, user_id, ctime, utime, account, password, email, username, avatar, role, token_version, locale, deleted_at, disabled, must_reset_password
+`
+
+type SetDisabledByUserIDParams struct {
+	UserID   pgtype.UUID
+	Disabled bool
+	Utime    int64
+}
+
+func (q *Queries) SetDisabledByUserID(ctx context.Context, arg SetDisabledByUserIDParams) (User, error) {
+	row := q.db.QueryRow(ctx, setDisabledByUserID, arg.UserID, arg.Disabled, arg.Utime)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Ctime,
+		&i.Utime,
+		&i.Account,
+		&i.Password,
+		&i.Email,
+		&i.Username,
+		&i.Avatar,
+		&i.Role,
+		&i.TokenVersion,
+		&i.Locale,
+		&i.DeletedAt,
+		&i.Disabled,
+		&i.MustResetPassword,
+	)
+	return i, err
+}
+
+const updateRoleByUserID = `-- name: UpdateRoleByUserID :one
+UPDATE "user"
+SET role = $2, token_version = token_version + 1, utime = $3
+WHERE user_id = $1
+RETURNING id, user_id, ctime, utime, account, password, email, username, avatar, role, token_version, locale, deleted_at, disabled, must_reset_password
+`
+
+type UpdateRoleByUserIDParams struct {
+	UserID pgtype.UUID
+	Role   int16
+	Utime  int64
+}
+
+// 递增令牌版本，旧令牌中的角色立即失效，刷新令牌换取的新令牌使用新角色
+func (q *Queries) UpdateRoleByUserID(ctx context.Context, arg UpdateRoleByUserIDParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateRoleByUserID, arg.UserID, arg.Role, arg.Utime)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Ctime,
+		&i.Utime,
+		&i.Account,
+		&i.Password,
+		&i.Email,
+		&i.Username,
+		&i.Avatar,
+		&i.Role,
+		&i.TokenVersion,
+		&i.Locale,
+		&i.DeletedAt,
+		&i.Disabled,
+		&i.MustResetPassword,
+	)
+	return i, err
+}
@@ -0,0 +1,143 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+
+package admin
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// 邮箱修改记录
+type EmailChange struct {
+	// 主键ID
+	ID int64
+	// 用户ID
+	UserID pgtype.UUID
+	// 修改前的邮箱
+	OldEmail string
+	// 修改后的邮箱
+	NewEmail string
+	// 撤销令牌的 SHA-256 摘要，不保存明文
+	RevertHash string
+	// 创建时间
+	Ctime int64
+	// 撤销链接的过期时间
+	ExpireTime int64
+	// 是否已被撤销
+	Reverted bool
+}
+
+// 邮件发件箱
+type EmailOutbox struct {
+	// 主键ID
+	ID int64
+	// 邮件ID，用于查询投递状态
+	MessageID pgtype.UUID
+	// 发起发送的用户，未登录时发送的邮件为空
+	UserID pgtype.UUID
+	// 收件人
+	Recipient string
+	// 邮件模板名
+	Template string
+	// 邮件语言
+	Locale string
+	// 邮件主题
+	Subject string
+	// HTML 正文，投递成功或进入死信后清空
+	Html string
+	// 纯文本正文，投递成功或进入死信后清空
+	Text string
+	// 状态：0 待投递，1 投递中，2 已投递，3 死信
+	Status int16
+	// 已尝试投递的次数
+	Attempts int32
+	// 下一次投递时间
+	NextAttemptAt int64
+	// 投递中的租约到期时间，到期仍未完成时重新投递
+	LockedUntil int64
+	// 最近一次投递失败的原因
+	LastError string
+	// 过期时间，过期仍未投递的邮件直接进入死信，0 表示不过期
+	ExpireAt int64
+	// 创建时间
+	Ctime int64
+	// 更新时间
+	Utime int64
+}
+
+// 刷新令牌表
+type RefreshToken struct {
+	// 主键ID
+	ID int64
+	// 令牌的 SHA-256 摘要，不保存明文
+	TokenHash string
+	// 令牌家族ID，轮换出的新令牌沿用同一个
+	FamilyID pgtype.UUID
+	// 用户ID
+	UserID pgtype.UUID
+	// 创建时间
+	Ctime int64
+	// 过期时间
+	ExpireTime int64
+	// 是否已被轮换
+	Used bool
+	// 是否已被吊销
+	Revoked bool
+}
+
+// 用户表
+type User struct {
+	// 主键ID
+	ID int64
+	// 用户ID
+	UserID pgtype.UUID
+	// 创建时间
+	Ctime int64
+	// 更新时间
+	Utime int64
+	// 账号
+	Account string
+	// 密码（argon2id 哈希）
+	Password string
+	// 邮箱
+	Email string
+	// 用户名
+	Username string
+	// 头像
+	Avatar string
+	// 角色
+	Role int16
+	// 令牌版本，修改密码时递增使已签发的令牌失效
+	TokenVersion int32
+	// 偏好语言，例如 zh-CN、en-US，为空时按请求的 Accept-Language
+	Locale string
+	// 申请注销的时间，0 表示未注销，宽限期过后由后台任务彻底删除
+	DeletedAt int64
+	// 是否被管理员禁用，禁用后无法登录
+	Disabled bool
+	// 是否被管理员要求重置密码，重置前无法登录
+	MustResetPassword bool
+}
+
+// 登录会话表
+type UserSession struct {
+	// 主键ID
+	ID int64
+	// 会话ID
+	SessionID pgtype.UUID
+	// 用户ID
+	UserID pgtype.UUID
+	// 登录设备的 User-Agent
+	UserAgent string
+	// 最近一次访问的IP
+	Ip string
+	// 创建时间
+	Ctime int64
+	// 最近一次访问时间
+	LastSeen int64
+	// 过期时间，随刷新令牌轮换延长
+	ExpireTime int64
+	// 是否已被注销
+	Revoked bool
+}
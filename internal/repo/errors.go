@@ -1,13 +1,31 @@
 package repo
 
-import "errors"
+import (
+	"net/http"
+	"nurture/internal/pkg/errorx"
+)
+
+var (
+	ErrDefault = errorx.ErrInternal
+)
+
+var (
+	ErrEmailIsUsed   = errorx.New(30006, http.StatusConflict, "user.email_is_used", "邮箱已经被使用")
+	ErrAccountIsUsed = errorx.New(30007, http.StatusConflict, "user.account_is_used", "账号已经被使用")
+	ErrUserNotExist  = errorx.New(30008, http.StatusNotFound, "user.not_exist", "用户不存在")
+	// 撤销链接无效时不区分具体原因
+	ErrEmailChangeInvalid = errorx.New(30012, http.StatusBadRequest, "user.email_revert_invalid", "链接无效或已过期")
+)
+
+var (
+	ErrTokenNotExist = errorx.New(20009, http.StatusUnauthorized, "repo.token_not_exist", "令牌不存在")
+	ErrTokenUsed     = errorx.New(20010, http.StatusUnauthorized, "repo.token_used", "令牌已被使用或吊销")
+)
 
 var (
-	ErrDefault = errors.New("默认错误")
+	ErrSessionNotExist = errorx.New(20007, http.StatusNotFound, "session.not_exist", "会话不存在或已注销")
 )
 
 var (
-	ErrEmailIsUsed   = errors.New("邮箱已经被使用")
-	ErrAccountIsUsed = errors.New("账号已经被使用")
-	ErrUserNotExist  = errors.New("用户不存在")
+	ErrEmailNotExist = errorx.New(50001, http.StatusNotFound, "email.not_exist", "邮件不存在")
 )
@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/global"
+	"nurture/internal/repo/outbox"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type IOutboxRepo interface {
+	Enqueue(ctx context.Context, email OutboxEmail) error
+	Claim(ctx context.Context, batchSize int32, lease time.Duration) ([]outbox.EmailOutbox, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkRetry(ctx context.Context, id int64, next time.Time, reason string) error
+	MarkDead(ctx context.Context, id int64, reason string) error
+	Release(ctx context.Context, id int64) error
+	GetOwnedEmail(ctx context.Context, messageID, userID, email string) (outbox.EmailOutbox, error)
+}
+type OutboxRepo struct {
+	outboxDao *outbox.Queries
+}
+
+func NewOutboxRepo() *OutboxRepo {
+	return &OutboxRepo{
+		outboxDao: outbox.New(global.DB),
+	}
+}
+
+var _ IOutboxRepo = (*OutboxRepo)(nil)
+
+// OutboxEmail 写入发件箱的邮件，UserID 为空表示未登录时发送，ExpireAt 为 0 表示不过期
+type OutboxEmail struct {
+	MessageID string
+	UserID    string
+	To        string
+	Template  string
+	Locale    string
+	Subject   string
+	HTML      string
+	Text      string
+	ExpireAt  int64
+}
+
+func (or *OutboxRepo) Enqueue(ctx context.Context, email OutboxEmail) error {
+	var messageUUID, userUUID pgtype.UUID
+	if err := messageUUID.Scan(email.MessageID); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if email.UserID != "" {
+		if err := userUUID.Scan(email.UserID); err != nil {
+			return ErrUserNotExist
+		}
+	}
+	now := time.Now().UnixMilli()
+	err := or.outboxDao.CreateOutboxEmail(ctx, outbox.CreateOutboxEmailParams{
+		MessageID:     messageUUID,
+		UserID:        userUUID,
+		Recipient:     email.To,
+		Template:      email.Template,
+		Locale:        email.Locale,
+		Subject:       email.Subject,
+		Html:          email.HTML,
+		Text:          email.Text,
+		NextAttemptAt: now,
+		ExpireAt:      email.ExpireAt,
+		Ctime:         now,
+		Utime:         now,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+// Claim 领取最多 batchSize 封到期的邮件并加上租约，租约到期前不会被再次领取
+func (or *OutboxRepo) Claim(ctx context.Context, batchSize int32, lease time.Duration) ([]outbox.EmailOutbox, error) {
+	now := time.Now()
+	rows, err := or.outboxDao.ClaimOutboxEmails(ctx, outbox.ClaimOutboxEmailsParams{
+		LockedUntil: now.Add(lease).UnixMilli(),
+		Now:         now.UnixMilli(),
+		BatchSize:   batchSize,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			global.Logger(ctx).Error(err)
+		}
+		return nil, ErrDefault
+	}
+	return rows, nil
+}
+
+func (or *OutboxRepo) MarkSent(ctx context.Context, id int64) error {
+	err := or.outboxDao.MarkOutboxEmailSent(ctx, outbox.MarkOutboxEmailSentParams{
+		ID:    id,
+		Utime: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+func (or *OutboxRepo) MarkRetry(ctx context.Context, id int64, next time.Time, reason string) error {
+	err := or.outboxDao.MarkOutboxEmailRetry(ctx, outbox.MarkOutboxEmailRetryParams{
+		ID:            id,
+		NextAttemptAt: next.UnixMilli(),
+		LastError:     reason,
+		Utime:         time.Now().UnixMilli(),
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+// MarkDead 邮件进入死信，同时清空正文
+func (or *OutboxRepo) MarkDead(ctx context.Context, id int64, reason string) error {
+	err := or.outboxDao.MarkOutboxEmailDead(ctx, outbox.MarkOutboxEmailDeadParams{
+		ID:        id,
+		LastError: reason,
+		Utime:     time.Now().UnixMilli(),
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+// Release 把已领取但还没开始投递的邮件放回发件箱，不计入投递次数
+func (or *OutboxRepo) Release(ctx context.Context, id int64) error {
+	err := or.outboxDao.ReleaseOutboxEmail(ctx, outbox.ReleaseOutboxEmailParams{
+		ID:  id,
+		Now: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+// GetOwnedEmail 查询属于用户的邮件：由 userID 发起，或发往用户当前的邮箱 email
+func (or *OutboxRepo) GetOwnedEmail(ctx context.Context, messageID, userID, email string) (outbox.EmailOutbox, error) {
+	var messageUUID, userUUID pgtype.UUID
+	if err := messageUUID.Scan(messageID); err != nil {
+		return outbox.EmailOutbox{}, ErrEmailNotExist
+	}
+	if err := userUUID.Scan(userID); err != nil {
+		return outbox.EmailOutbox{}, ErrEmailNotExist
+	}
+	e, err := or.outboxDao.GetOwnedOutboxEmail(ctx, outbox.GetOwnedOutboxEmailParams{
+		MessageID: messageUUID,
+		UserID:    userUUID,
+		Recipient: email,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return outbox.EmailOutbox{}, ErrEmailNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return outbox.EmailOutbox{}, ErrDefault
+	}
+	return e, nil
+}
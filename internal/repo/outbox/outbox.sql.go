@@ -0,0 +1,224 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: outbox.sql
+
+package outbox
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimOutboxEmails = `-- name: ClaimOutboxEmails :many
+UPDATE email_outbox
+SET status = 1, attempts = attempts + 1, locked_until = $1, utime = $2
+WHERE id IN (
+  SELECT id FROM email_outbox
+  WHERE (status = 0 AND next_attempt_at <= $2)
+     OR (status = 1 AND locked_until <= $2)
+  ORDER BY next_attempt_at
+  LIMIT $3
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, message_id, user_id, recipient, template, locale, subject, html, text, status, attempts, next_attempt_at, locked_until, last_error, expire_at, ctime, utime
+`
+
+type ClaimOutboxEmailsParams struct {
+	LockedUntil int64
+	Now         int64
+	BatchSize   int32
+}
+
+// 领取到期的待投递邮件，以及租约已过期（worker 崩溃或超时）的投递中邮件
+func (q *Queries) ClaimOutboxEmails(ctx context.Context, arg ClaimOutboxEmailsParams) ([]EmailOutbox, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEmails, arg.LockedUntil, arg.Now, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EmailOutbox
+	for rows.Next() {
+		var i EmailOutbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.UserID,
+			&i.Recipient,
+			&i.Template,
+			&i.Locale,
+			&i.Subject,
+			&i.Html,
+			&i.Text,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LockedUntil,
+			&i.LastError,
+			&i.ExpireAt,
+			&i.Ctime,
+			&i.Utime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createOutboxEmail = `-- name: CreateOutboxEmail :exec
+INSERT INTO email_outbox (
+  message_id, user_id, recipient, template, locale, subject, html, text, next_attempt_at, expire_at, ctime, utime
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+`
+
+type CreateOutboxEmailParams struct {
+	MessageID     pgtype.UUID
+	UserID        pgtype.UUID
+	Recipient     string
+	Template      string
+	Locale        string
+	Subject       string
+	Html          string
+	Text          string
+	NextAttemptAt int64
+	ExpireAt      int64
+	Ctime         int64
+	Utime         int64
+}
+
+func (q *Queries) CreateOutboxEmail(ctx context.Context, arg CreateOutboxEmailParams) error {
+	_, err := q.db.Exec(ctx, createOutboxEmail,
+		arg.MessageID,
+		arg.UserID,
+		arg.Recipient,
+		arg.Template,
+		arg.Locale,
+		arg.Subject,
+		arg.Html,
+		arg.Text,
+		arg.NextAttemptAt,
+		arg.ExpireAt,
+		arg.Ctime,
+		arg.Utime,
+	)
+	return err
+}
+
+const getOwnedOutboxEmail = `-- name: GetOwnedOutboxEmail :one
+SELECT id, message_id, user_id, recipient, template, locale, subject, html, text, status, attempts, next_attempt_at, locked_until, last_error, expire_at, ctime, utime FROM email_outbox
+WHERE message_id = $1
+  AND (user_id = $2 OR recipient = $3)
+LIMIT 1
+`
+
+type GetOwnedOutboxEmailParams struct {
+	MessageID pgtype.UUID
+	UserID    pgtype.UUID
+	Recipient string
+}
+
+// 只能查询自己发起的邮件，或发往自己当前邮箱的邮件
+func (q *Queries) GetOwnedOutboxEmail(ctx context.Context, arg GetOwnedOutboxEmailParams) (EmailOutbox, error) {
+	row := q.db.QueryRow(ctx, getOwnedOutboxEmail, arg.MessageID, arg.UserID, arg.Recipient)
+	var i EmailOutbox
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.UserID,
+		&i.Recipient,
+		&i.Template,
+		&i.Locale,
+		&i.Subject,
+		&i.Html,
+		&i.Text,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LockedUntil,
+		&i.LastError,
+		&i.ExpireAt,
+		&i.Ctime,
+		&i.Utime,
+	)
+	return i, err
+}
+
+const markOutboxEmailDead = `-- name: MarkOutboxEmailDead :exec
+UPDATE email_outbox
+SET status = 3, html = '', text = '', last_error = $2, utime = $3
+WHERE id = $1
+`
+
+type MarkOutboxEmailDeadParams struct {
+	ID        int64
+	LastError string
+	Utime     int64
+}
+
+func (q *Queries) MarkOutboxEmailDead(ctx context.Context, arg MarkOutboxEmailDeadParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEmailDead, arg.ID, arg.LastError, arg.Utime)
+	return err
+}
+
+const markOutboxEmailRetry = `-- name: MarkOutboxEmailRetry :exec
+UPDATE email_outbox
+SET status = 0, next_attempt_at = $2, last_error = $3, utime = $4
+WHERE id = $1
+`
+
+type MarkOutboxEmailRetryParams struct {
+	ID            int64
+	NextAttemptAt int64
+	LastError     string
+	Utime         int64
+}
+
+func (q *Queries) MarkOutboxEmailRetry(ctx context.Context, arg MarkOutboxEmailRetryParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEmailRetry,
+		arg.ID,
+		arg.NextAttemptAt,
+		arg.LastError,
+		arg.Utime,
+	)
+	return err
+}
+
+const markOutboxEmailSent = `-- name: MarkOutboxEmailSent :exec
+UPDATE email_outbox
+SET status = 2, html = '', text = '', last_error = '', utime = $2
+WHERE id = $1
+`
+
+type MarkOutboxEmailSentParams struct {
+	ID    int64
+	Utime int64
+}
+
+func (q *Queries) MarkOutboxEmailSent(ctx context.Context, arg MarkOutboxEmailSentParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEmailSent, arg.ID, arg.Utime)
+	return err
+}
+
+const releaseOutboxEmail = `-- name: ReleaseOutboxEmail :exec
+UPDATE email_outbox
+SET status = 0, attempts = GREATEST(attempts - 1, 0), locked_until = 0, next_attempt_at = $1, utime = $1
+WHERE id = $2 AND status = 1
+`
+
+type ReleaseOutboxEmailParams struct {
+	Now int64
+	ID  int64
+}
+
+// 把已领取但还没开始投递的邮件放回发件箱，撤销领取时增加的投递次数
+func (q *Queries) ReleaseOutboxEmail(ctx context.Context, arg ReleaseOutboxEmailParams) error {
+	_, err := q.db.Exec(ctx, releaseOutboxEmail, arg.Now, arg.ID)
+	return err
+}
@@ -0,0 +1,212 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/global"
+	"nurture/internal/repo/session"
+	"nurture/internal/repo/token"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ISessionRepo interface {
+	CreateSession(ctx context.Context, sessionID, userID, userAgent, ip string, expireTime int64) error
+	GetSession(ctx context.Context, sessionID string) (session.UserSession, error)
+	ListActiveSessions(ctx context.Context, userID string) ([]session.UserSession, error)
+	TouchSession(ctx context.Context, sessionID, ip string) error
+	ExtendSession(ctx context.Context, sessionID string, expireTime int64) error
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) (int64, error)
+}
+type SessionRepo struct {
+	sessionDao *session.Queries
+	tokenDao   *token.Queries
+}
+
+func NewSessionRepo() *SessionRepo {
+	return &SessionRepo{
+		sessionDao: session.New(global.DB),
+		tokenDao:   token.New(global.DB),
+	}
+}
+
+var _ ISessionRepo = (*SessionRepo)(nil)
+
+func (sr *SessionRepo) CreateSession(ctx context.Context, sessionID, userID, userAgent, ip string, expireTime int64) error {
+	var sessionUUID, userUUID pgtype.UUID
+	if err := sessionUUID.Scan(sessionID); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if err := userUUID.Scan(userID); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	now := time.Now().UnixMilli()
+	err := sr.sessionDao.CreateSession(ctx, session.CreateSessionParams{
+		SessionID:  sessionUUID,
+		UserID:     userUUID,
+		UserAgent:  truncate(userAgent, 255),
+		Ip:         truncate(ip, 64),
+		Ctime:      now,
+		LastSeen:   now,
+		ExpireTime: expireTime,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+func (sr *SessionRepo) GetSession(ctx context.Context, sessionID string) (session.UserSession, error) {
+	var sessionUUID pgtype.UUID
+	if err := sessionUUID.Scan(sessionID); err != nil {
+		return session.UserSession{}, ErrSessionNotExist
+	}
+	s, err := sr.sessionDao.GetSessionBySessionID(ctx, sessionUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return session.UserSession{}, ErrSessionNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return session.UserSession{}, ErrDefault
+	}
+	return s, nil
+}
+
+func (sr *SessionRepo) ListActiveSessions(ctx context.Context, userID string) ([]session.UserSession, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return nil, ErrUserNotExist
+	}
+	list, err := sr.sessionDao.ListActiveSessionsByUserID(ctx, session.ListActiveSessionsByUserIDParams{
+		UserID:     userUUID,
+		ExpireTime: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return nil, ErrDefault
+	}
+	return list, nil
+}
+
+func (sr *SessionRepo) TouchSession(ctx context.Context, sessionID, ip string) error {
+	var sessionUUID pgtype.UUID
+	if err := sessionUUID.Scan(sessionID); err != nil {
+		return ErrSessionNotExist
+	}
+	err := sr.sessionDao.TouchSession(ctx, session.TouchSessionParams{
+		SessionID: sessionUUID,
+		LastSeen:  time.Now().UnixMilli(),
+		Ip:        truncate(ip, 64),
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+func (sr *SessionRepo) ExtendSession(ctx context.Context, sessionID string, expireTime int64) error {
+	var sessionUUID pgtype.UUID
+	if err := sessionUUID.Scan(sessionID); err != nil {
+		return ErrSessionNotExist
+	}
+	err := sr.sessionDao.ExtendSession(ctx, session.ExtendSessionParams{
+		SessionID:  sessionUUID,
+		ExpireTime: expireTime,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+// RevokeSession 注销会话，并在同一个事务中吊销该会话的刷新令牌家族
+func (sr *SessionRepo) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	var sessionUUID, userUUID pgtype.UUID
+	if err := sessionUUID.Scan(sessionID); err != nil {
+		return ErrSessionNotExist
+	}
+	if err := userUUID.Scan(userID); err != nil {
+		return ErrSessionNotExist
+	}
+	tx, err := global.DB.Begin(ctx)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	defer tx.Rollback(ctx)
+	count, err := sr.sessionDao.WithTx(tx).RevokeSession(ctx, session.RevokeSessionParams{
+		SessionID: sessionUUID,
+		UserID:    userUUID,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if count == 0 {
+		return ErrSessionNotExist
+	}
+	if _, err := sr.tokenDao.WithTx(tx).RevokeRefreshTokenFamily(ctx, sessionUUID); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if err := tx.Commit(ctx); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+// RevokeOtherSessions 注销除 keepSessionID 以外的所有会话及其刷新令牌，返回注销的会话数
+func (sr *SessionRepo) RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) (int64, error) {
+	var keepUUID, userUUID pgtype.UUID
+	if err := keepUUID.Scan(keepSessionID); err != nil {
+		return 0, ErrSessionNotExist
+	}
+	if err := userUUID.Scan(userID); err != nil {
+		return 0, ErrSessionNotExist
+	}
+	tx, err := global.DB.Begin(ctx)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return 0, ErrDefault
+	}
+	defer tx.Rollback(ctx)
+	count, err := sr.sessionDao.WithTx(tx).RevokeOtherSessions(ctx, session.RevokeOtherSessionsParams{
+		UserID:    userUUID,
+		SessionID: keepUUID,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return 0, ErrDefault
+	}
+	_, err = sr.tokenDao.WithTx(tx).RevokeOtherRefreshTokenFamilies(ctx, token.RevokeOtherRefreshTokenFamiliesParams{
+		UserID:   userUUID,
+		FamilyID: keepUUID,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return 0, ErrDefault
+	}
+	if err := tx.Commit(ctx); err != nil {
+		global.Logger(ctx).Error(err)
+		return 0, ErrDefault
+	}
+	return count, nil
+}
+
+// truncate 按字符截断过长的字符串，避免超出列宽
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,191 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: session.sql
+
+package session
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createSession = `-- name: CreateSession :exec
+INSERT INTO user_session (
+  session_id, user_id, user_agent, ip, ctime, last_seen, expire_time
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+`
+
+type CreateSessionParams struct {
+	SessionID  pgtype.UUID
+	UserID     pgtype.UUID
+	UserAgent  string
+	Ip         string
+	Ctime      int64
+	LastSeen   int64
+	ExpireTime int64
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) error {
+	_, err := q.db.Exec(ctx, createSession,
+		arg.SessionID,
+		arg.UserID,
+		arg.UserAgent,
+		arg.Ip,
+		arg.Ctime,
+		arg.LastSeen,
+		arg.ExpireTime,
+	)
+	return err
+}
+
+const extendSession = `-- name: ExtendSession :exec
+UPDATE user_session
+SET expire_time = $2
+WHERE session_id = $1
+`
+
+type ExtendSessionParams struct {
+	SessionID  pgtype.UUID
+	ExpireTime int64
+}
+
+func (q *Queries) ExtendSession(ctx context.Context, arg ExtendSessionParams) error {
+	_, err := q.db.Exec(ctx, extendSession, arg.SessionID, arg.ExpireTime)
+	return err
+}
+
+const getSessionBySessionID = `-- name: GetSessionBySessionID :one
+SELECT id, session_id, user_id, user_agent, ip, ctime, last_seen, expire_time, revoked FROM user_session
+WHERE session_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetSessionBySessionID(ctx context.Context, sessionID pgtype.UUID) (UserSession, error) {
+	row := q.db.QueryRow(ctx, getSessionBySessionID, sessionID)
+	var i UserSession
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.UserID,
+		&i.UserAgent,
+		&i.Ip,
+		&i.Ctime,
+		&i.LastSeen,
+		&i.ExpireTime,
+		&i.Revoked,
+	)
+	return i, err
+}
+
+const listActiveSessionsByUserID = `-- name: ListActiveSessionsByUserID :many
+SELECT id, session_id, user_id, user_agent, ip, ctime, last_seen, expire_time, revoked FROM user_session
+WHERE user_id = $1 AND revoked = FALSE AND expire_time > $2
+ORDER BY last_seen DESC
+`
+
+type ListActiveSessionsByUserIDParams struct {
+	UserID     pgtype.UUID
+	ExpireTime int64
+}
+
+func (q *Queries) ListActiveSessionsByUserID(ctx context.Context, arg ListActiveSessionsByUserIDParams) ([]UserSession, error) {
+	rows, err := q.db.Query(ctx, listActiveSessionsByUserID, arg.UserID, arg.ExpireTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserSession
+	for rows.Next() {
+		var i UserSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.UserID,
+			&i.UserAgent,
+			&i.Ip,
+			&i.Ctime,
+			&i.LastSeen,
+			&i.ExpireTime,
+			&i.Revoked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeAllSessions = `-- name: RevokeAllSessions :execrows
+UPDATE user_session
+SET revoked = TRUE
+WHERE user_id = $1 AND revoked = FALSE
+`
+
+func (q *Queries) RevokeAllSessions(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeAllSessions, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeOtherSessions = `-- name: RevokeOtherSessions :execrows
+UPDATE user_session
+SET revoked = TRUE
+WHERE user_id = $1 AND session_id <> $2 AND revoked = FALSE
+`
+
+type RevokeOtherSessionsParams struct {
+	UserID    pgtype.UUID
+	SessionID pgtype.UUID
+}
+
+func (q *Queries) RevokeOtherSessions(ctx context.Context, arg RevokeOtherSessionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeOtherSessions, arg.UserID, arg.SessionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeSession = `-- name: RevokeSession :execrows
+UPDATE user_session
+SET revoked = TRUE
+WHERE session_id = $1 AND user_id = $2 AND revoked = FALSE
+`
+
+type RevokeSessionParams struct {
+	SessionID pgtype.UUID
+	UserID    pgtype.UUID
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeSession, arg.SessionID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const touchSession = `-- name: TouchSession :exec
+UPDATE user_session
+SET last_seen = $2, ip = $3
+WHERE session_id = $1
+`
+
+type TouchSessionParams struct {
+	SessionID pgtype.UUID
+	LastSeen  int64
+	Ip        string
+}
+
+func (q *Queries) TouchSession(ctx context.Context, arg TouchSessionParams) error {
+	_, err := q.db.Exec(ctx, touchSession, arg.SessionID, arg.LastSeen, arg.Ip)
+	return err
+}
@@ -0,0 +1,61 @@
+-- name: GetUserByUserID :one
+SELECT * FROM "user"
+WHERE user_id = $1 LIMIT 1;
+
+-- name: SoftDeleteUser :execrows
+-- 标记注销并递增令牌版本，已签发的访问令牌立即失效
+UPDATE "user"
+SET deleted_at = $2, token_version = token_version + 1, utime = $2
+WHERE user_id = $1 AND deleted_at = 0;
+
+-- name: RestoreUser :execrows
+UPDATE "user"
+SET deleted_at = 0, utime = $2
+WHERE user_id = $1 AND deleted_at > 0;
+
+-- name: ListPurgeableUsers :many
+-- 宽限期已过的注销账号，SKIP LOCKED 保证多个实例不会重复处理
+SELECT user_id FROM "user"
+WHERE deleted_at > 0 AND deleted_at < sqlc.arg(before)
+ORDER BY deleted_at
+LIMIT sqlc.arg(batch_size)
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeleteOutboxEmailsOfUsers :execrows
+-- 删除发往这些用户当前及历史邮箱的邮件，已被其他用户使用的邮箱除外
+DELETE FROM email_outbox
+WHERE recipient IN (
+  SELECT email FROM "user" WHERE user_id = ANY(sqlc.arg(user_ids)::uuid[])
+  UNION
+  SELECT old_email FROM email_change WHERE user_id = ANY(sqlc.arg(user_ids)::uuid[])
+  UNION
+  SELECT new_email FROM email_change WHERE user_id = ANY(sqlc.arg(user_ids)::uuid[])
+)
+AND recipient NOT IN (
+  SELECT email FROM "user" WHERE user_id <> ALL(sqlc.arg(user_ids)::uuid[])
+);
+
+-- name: DeleteUsers :execrows
+-- 会话、刷新令牌和邮箱修改记录通过外键级联删除
+DELETE FROM "user"
+WHERE user_id = ANY(sqlc.arg(user_ids)::uuid[]);
+
+-- name: ListSessionsByUserID :many
+SELECT * FROM user_session
+WHERE user_id = $1
+ORDER BY ctime;
+
+-- name: ListRefreshTokensByUserID :many
+SELECT family_id, ctime, expire_time, used, revoked FROM refresh_token
+WHERE user_id = $1
+ORDER BY ctime;
+
+-- name: ListEmailChangesByUserID :many
+SELECT old_email, new_email, ctime, expire_time, reverted FROM email_change
+WHERE user_id = $1
+ORDER BY ctime;
+
+-- name: ListOutboxEmailsByRecipients :many
+SELECT message_id, recipient, template, locale, subject, status, attempts, ctime, utime FROM email_outbox
+WHERE recipient = ANY(sqlc.arg(recipients)::varchar[])
+ORDER BY ctime;
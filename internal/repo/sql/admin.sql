@@ -0,0 +1,45 @@
+-- name: ListUsers :many
+-- 参数为 NULL 的筛选条件不生效，keyword 匹配账号、邮箱或用户名的子串
+SELECT * FROM "user"
+WHERE (sqlc.narg(keyword)::text IS NULL
+       OR strpos(lower(account), lower(sqlc.narg(keyword)::text)) > 0
+       OR strpos(lower(email), lower(sqlc.narg(keyword)::text)) > 0
+       OR strpos(lower(username), lower(sqlc.narg(keyword)::text)) > 0)
+  AND (sqlc.narg(role)::smallint IS NULL OR role = sqlc.narg(role)::smallint)
+  AND (sqlc.narg(disabled)::boolean IS NULL OR disabled = sqlc.narg(disabled)::boolean)
+  AND (sqlc.narg(deleted)::boolean IS NULL OR (deleted_at > 0) = sqlc.narg(deleted)::boolean)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit_count) OFFSET sqlc.arg(offset_count);
+
+-- name: CountUsers :one
+SELECT count(*) FROM "user"
+WHERE (sqlc.narg(keyword)::text IS NULL
+       OR strpos(lower(account), lower(sqlc.narg(keyword)::text)) > 0
+       OR strpos(lower(email), lower(sqlc.narg(keyword)::text)) > 0
+       OR strpos(lower(username), lower(sqlc.narg(keyword)::text)) > 0)
+  AND (sqlc.narg(role)::smallint IS NULL OR role = sqlc.narg(role)::smallint)
+  AND (sqlc.narg(disabled)::boolean IS NULL OR disabled = sqlc.narg(disabled)::boolean)
+  AND (sqlc.narg(deleted)::boolean IS NULL OR (deleted_at > 0) = sqlc.narg(deleted)::boolean);
+
+-- name: GetUserByUserID :one
+SELECT * FROM "user"
+WHERE user_id = $1 LIMIT 1;
+
+-- name: UpdateRoleByUserID :one
+-- 递增令牌版本，旧令牌中的角色立即失效，刷新令牌换取的新令牌使用新角色
+UPDATE "user"
+SET role = $2, token_version = token_version + 1, utime = $3
+WHERE user_id = $1
+RETURNING *;
+
+-- name: SetDisabledByUserID :one
+UPDATE "user"
+SET disabled = $2, token_version = token_version + 1, utime = $3
+WHERE user_id = $1
+RETURNING *;
+
+-- name: RequirePasswordResetByUserID :one
+UPDATE "user"
+SET must_reset_password = TRUE, token_version = token_version + 1, utime = $2
+WHERE user_id = $1
+RETURNING *;
@@ -0,0 +1,48 @@
+-- name: CreateOutboxEmail :exec
+INSERT INTO email_outbox (
+  message_id, user_id, recipient, template, locale, subject, html, text, next_attempt_at, expire_at, ctime, utime
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+);
+
+-- name: GetOwnedOutboxEmail :one
+-- 只能查询自己发起的邮件，或发往自己当前邮箱的邮件
+SELECT * FROM email_outbox
+WHERE message_id = sqlc.arg(message_id)
+  AND (user_id = sqlc.arg(user_id) OR recipient = sqlc.arg(recipient))
+LIMIT 1;
+
+-- name: ClaimOutboxEmails :many
+-- 领取到期的待投递邮件，以及租约已过期（worker 崩溃或超时）的投递中邮件
+UPDATE email_outbox
+SET status = 1, attempts = attempts + 1, locked_until = sqlc.arg(locked_until), utime = sqlc.arg(now)
+WHERE id IN (
+  SELECT id FROM email_outbox
+  WHERE (status = 0 AND next_attempt_at <= sqlc.arg(now))
+     OR (status = 1 AND locked_until <= sqlc.arg(now))
+  ORDER BY next_attempt_at
+  LIMIT sqlc.arg(batch_size)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEmailSent :exec
+UPDATE email_outbox
+SET status = 2, html = '', text = '', last_error = '', utime = $2
+WHERE id = $1;
+
+-- name: MarkOutboxEmailRetry :exec
+UPDATE email_outbox
+SET status = 0, next_attempt_at = $2, last_error = $3, utime = $4
+WHERE id = $1;
+
+-- name: ReleaseOutboxEmail :exec
+-- 把已领取但还没开始投递的邮件放回发件箱，撤销领取时增加的投递次数
+UPDATE email_outbox
+SET status = 0, attempts = GREATEST(attempts - 1, 0), locked_until = 0, next_attempt_at = sqlc.arg(now), utime = sqlc.arg(now)
+WHERE id = sqlc.arg(id) AND status = 1;
+
+-- name: MarkOutboxEmailDead :exec
+UPDATE email_outbox
+SET status = 3, html = '', text = '', last_error = $2, utime = $3
+WHERE id = $1;
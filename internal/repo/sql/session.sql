@@ -0,0 +1,40 @@
+-- name: CreateSession :exec
+INSERT INTO user_session (
+  session_id, user_id, user_agent, ip, ctime, last_seen, expire_time
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: GetSessionBySessionID :one
+SELECT * FROM user_session
+WHERE session_id = $1 LIMIT 1;
+
+-- name: ListActiveSessionsByUserID :many
+SELECT * FROM user_session
+WHERE user_id = $1 AND revoked = FALSE AND expire_time > $2
+ORDER BY last_seen DESC;
+
+-- name: TouchSession :exec
+UPDATE user_session
+SET last_seen = $2, ip = $3
+WHERE session_id = $1;
+
+-- name: ExtendSession :exec
+UPDATE user_session
+SET expire_time = $2
+WHERE session_id = $1;
+
+-- name: RevokeSession :execrows
+UPDATE user_session
+SET revoked = TRUE
+WHERE session_id = $1 AND user_id = $2 AND revoked = FALSE;
+
+-- name: RevokeOtherSessions :execrows
+UPDATE user_session
+SET revoked = TRUE
+WHERE user_id = $1 AND session_id <> $2 AND revoked = FALSE;
+
+-- name: RevokeAllSessions :execrows
+UPDATE user_session
+SET revoked = TRUE
+WHERE user_id = $1 AND revoked = FALSE;
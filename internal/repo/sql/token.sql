@@ -0,0 +1,30 @@
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_token (
+  token_hash, family_id, user_id, ctime, expire_time
+) VALUES (
+  $1, $2, $3, $4, $5
+);
+
+-- name: GetRefreshTokenByHash :one
+SELECT * FROM refresh_token
+WHERE token_hash = $1 LIMIT 1;
+
+-- name: UseRefreshToken :execrows
+UPDATE refresh_token
+SET used = TRUE
+WHERE id = $1 AND used = FALSE AND revoked = FALSE;
+
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE family_id = $1 AND revoked = FALSE;
+
+-- name: RevokeOtherRefreshTokenFamilies :execrows
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE user_id = $1 AND family_id <> $2 AND revoked = FALSE;
+
+-- name: RevokeAllRefreshTokens :execrows
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE user_id = $1 AND revoked = FALSE;
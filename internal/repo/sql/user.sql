@@ -1,6 +1,6 @@
--- name: GetUserByAccountAndPassword :one
+-- name: GetUserByAccount :one
 SELECT * FROM "user"
-WHERE account = $1 AND password = $2 LIMIT 1;
+WHERE account = $1 LIMIT 1;
 
 -- name: GetUserByEmail :one
 SELECT * FROM "user"
@@ -8,17 +8,90 @@ WHERE email = $1 LIMIT 1;
 
 -- name: CreateUser :exec
 INSERT INTO "user" (
-  user_id, ctime, utime, account, password, email, username, avatar, role
+  user_id, ctime, utime, account, password, email, username, avatar, role, locale
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 );
 
--- name: UpdatePasswordByEmail :execrows
+-- name: UpdatePasswordByEmail :one
+UPDATE "user"
+SET password = $2, token_version = token_version + 1, must_reset_password = FALSE
+WHERE email = $1
+RETURNING user_id;
+
+-- name: UpdatePasswordByUserID :execrows
 UPDATE "user"
 SET password = $2
-WHERE email = $1;
+WHERE user_id = $1;
+
+-- name: UpdateAvatarByUserID :one
+-- 返回修改前的头像，用于删除旧的头像文件
+UPDATE "user" u
+SET avatar = sqlc.arg(avatar), utime = sqlc.arg(utime)
+FROM (SELECT o.user_id, o.avatar FROM "user" o WHERE o.user_id = sqlc.arg(user_id) FOR UPDATE) old
+WHERE u.user_id = old.user_id
+RETURNING old.avatar;
+
+-- name: UpdateProfileByUserID :one
+-- 参数为 NULL 的字段保持不变
+UPDATE "user"
+SET username = COALESCE(sqlc.narg(username), username),
+    avatar = COALESCE(sqlc.narg(avatar), avatar),
+    utime = sqlc.arg(utime)
+WHERE user_id = sqlc.arg(user_id)
+RETURNING *;
+
+-- name: ChangePasswordByUserID :one
+UPDATE "user"
+SET password = $2, token_version = token_version + 1, must_reset_password = FALSE, utime = $3
+WHERE user_id = $1
+RETURNING token_version;
+
+-- name: GetUserByUserID :one
+SELECT * FROM "user"
+WHERE user_id = $1 LIMIT 1;
+
+-- name: GetAuthStateByUserID :one
+SELECT token_version, locale FROM "user"
+WHERE user_id = $1 LIMIT 1;
 
--- name: UpdateAvatarByUserID :execrows
+-- name: UpdateLocaleByUserID :execrows
 UPDATE "user"
-SET avatar = $2
-WHERE user_id = $1;
\ No newline at end of file
+SET locale = $2, utime = $3
+WHERE user_id = $1;
+
+-- name: UpdateEmailByUserID :execrows
+-- 只有邮箱仍是 old_email 时才修改，防止并发修改互相覆盖
+UPDATE "user"
+SET email = sqlc.arg(new_email), utime = sqlc.arg(utime)
+WHERE user_id = sqlc.arg(user_id) AND email = sqlc.arg(old_email);
+
+-- name: RevertEmailByUserID :execrows
+-- 改回旧邮箱并递增令牌版本，使修改邮箱的人持有的令牌全部失效
+-- 不限制当前邮箱，邮箱被连续修改多次后，最初的撤销链接仍然能把邮箱改回来
+UPDATE "user"
+SET email = sqlc.arg(old_email), token_version = token_version + 1, utime = sqlc.arg(utime)
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: CreateEmailChange :exec
+INSERT INTO email_change (
+  user_id, old_email, new_email, revert_hash, ctime, expire_time
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+);
+
+-- name: GetEmailChangeForUpdate :one
+SELECT * FROM email_change
+WHERE revert_hash = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: MarkEmailChangeReverted :exec
+UPDATE email_change
+SET reverted = TRUE
+WHERE id = $1;
+
+-- name: InvalidateLaterEmailChanges :exec
+-- 撤销后作废该用户在此之后的修改记录，其撤销链接发往的邮箱可能由攻击者持有
+UPDATE email_change
+SET reverted = TRUE
+WHERE user_id = $1 AND id > $2 AND reverted = FALSE;
@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"nurture/internal/global"
+	"nurture/internal/repo/token"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ITokenRepo interface {
+	CreateRefreshToken(ctx context.Context, tokenHash, familyID, userID string, expireTime int64) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (token.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, old token.RefreshToken, tokenHash string, expireTime int64) error
+	RevokeFamily(ctx context.Context, familyID string) error
+}
+type TokenRepo struct {
+	tokenDao *token.Queries
+}
+
+func NewTokenRepo() *TokenRepo {
+	return &TokenRepo{
+		tokenDao: token.New(global.DB),
+	}
+}
+
+var _ ITokenRepo = (*TokenRepo)(nil)
+
+func (tr *TokenRepo) CreateRefreshToken(ctx context.Context, tokenHash, familyID, userID string, expireTime int64) error {
+	var familyUUID, userUUID pgtype.UUID
+	if err := familyUUID.Scan(familyID); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if err := userUUID.Scan(userID); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	err := tr.tokenDao.CreateRefreshToken(ctx, token.CreateRefreshTokenParams{
+		TokenHash:  tokenHash,
+		FamilyID:   familyUUID,
+		UserID:     userUUID,
+		Ctime:      time.Now().UnixMilli(),
+		ExpireTime: expireTime,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+func (tr *TokenRepo) GetRefreshToken(ctx context.Context, tokenHash string) (token.RefreshToken, error) {
+	t, err := tr.tokenDao.GetRefreshTokenByHash(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return token.RefreshToken{}, ErrTokenNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return token.RefreshToken{}, ErrDefault
+	}
+	return t, nil
+}
+
+// RotateRefreshToken 在同一个事务中把旧令牌标记为已使用并写入同一家族的新令牌
+// 旧令牌已被使用或吊销时返回 ErrTokenUsed，调用方应视为令牌重放
+func (tr *TokenRepo) RotateRefreshToken(ctx context.Context, old token.RefreshToken, tokenHash string, expireTime int64) error {
+	tx, err := global.DB.Begin(ctx)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	defer tx.Rollback(ctx)
+	q := tr.tokenDao.WithTx(tx)
+	count, err := q.UseRefreshToken(ctx, old.ID)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if count == 0 {
+		return ErrTokenUsed
+	}
+	err = q.CreateRefreshToken(ctx, token.CreateRefreshTokenParams{
+		TokenHash:  tokenHash,
+		FamilyID:   old.FamilyID,
+		UserID:     old.UserID,
+		Ctime:      time.Now().UnixMilli(),
+		ExpireTime: expireTime,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if err := tx.Commit(ctx); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+func (tr *TokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	var familyUUID pgtype.UUID
+	if err := familyUUID.Scan(familyID); err != nil {
+		return ErrTokenNotExist
+	}
+	if _, err := tr.tokenDao.RevokeRefreshTokenFamily(ctx, familyUUID); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: token.sql
+
+package token
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createRefreshToken = `-- name: CreateRefreshToken :exec
+INSERT INTO refresh_token (
+  token_hash, family_id, user_id, ctime, expire_time
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+`
+
+type CreateRefreshTokenParams struct {
+	TokenHash  string
+	FamilyID   pgtype.UUID
+	UserID     pgtype.UUID
+	Ctime      int64
+	ExpireTime int64
+}
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error {
+	_, err := q.db.Exec(ctx, createRefreshToken,
+		arg.TokenHash,
+		arg.FamilyID,
+		arg.UserID,
+		arg.Ctime,
+		arg.ExpireTime,
+	)
+	return err
+}
+
+const getRefreshTokenByHash = `-- name: GetRefreshTokenByHash :one
+SELECT id, token_hash, family_id, user_id, ctime, expire_time, used, revoked FROM refresh_token
+WHERE token_hash = $1 LIMIT 1
+`
+
+func (q *Queries) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRow(ctx, getRefreshTokenByHash, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.TokenHash,
+		&i.FamilyID,
+		&i.UserID,
+		&i.Ctime,
+		&i.ExpireTime,
+		&i.Used,
+		&i.Revoked,
+	)
+	return i, err
+}
+
+const revokeAllRefreshTokens = `-- name: RevokeAllRefreshTokens :execrows
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE user_id = $1 AND revoked = FALSE
+`
+
+func (q *Queries) RevokeAllRefreshTokens(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeAllRefreshTokens, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeOtherRefreshTokenFamilies = `-- name: RevokeOtherRefreshTokenFamilies :execrows
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE user_id = $1 AND family_id <> $2 AND revoked = FALSE
+`
+
+type RevokeOtherRefreshTokenFamiliesParams struct {
+	UserID   pgtype.UUID
+	FamilyID pgtype.UUID
+}
+
+func (q *Queries) RevokeOtherRefreshTokenFamilies(ctx context.Context, arg RevokeOtherRefreshTokenFamiliesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeOtherRefreshTokenFamilies, arg.UserID, arg.FamilyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_token
+SET revoked = TRUE
+WHERE family_id = $1 AND revoked = FALSE
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeRefreshTokenFamily, familyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const useRefreshToken = `-- name: UseRefreshToken :execrows
+UPDATE refresh_token
+SET used = TRUE
+WHERE id = $1 AND used = FALSE AND revoked = FALSE
+`
+
+func (q *Queries) UseRefreshToken(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, useRefreshToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
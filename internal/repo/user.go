@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"nurture/internal/global"
+	"nurture/internal/repo/session"
+	"nurture/internal/repo/token"
 	"nurture/internal/repo/user"
 	"time"
 
@@ -13,11 +15,19 @@ import (
 )
 
 type IUserRepo interface {
-	LoginWithAccount(ctx context.Context, account string, password string) (user.User, error)
+	LoginWithAccount(ctx context.Context, account string) (user.User, error)
 	LoginWithEmail(ctx context.Context, email string) (user.User, error)
-	Register(ctx context.Context, userID, username, email, account, password string) error //这个结构默认都注册普通用户
+	GetUserByID(ctx context.Context, userID string) (user.User, error)
+	Register(ctx context.Context, userID, username, email, account, password, locale string) error //这个结构默认都注册普通用户
 	ResetPassword(ctx context.Context, email, newPassword string) error
-	UpdateAvatarByID(ctx context.Context, userID, url string) error
+	UpdatePasswordByID(ctx context.Context, userID, password string) error
+	GetAuthState(ctx context.Context, userID string) (user.GetAuthStateByUserIDRow, error)
+	UpdateLocaleByID(ctx context.Context, userID, locale string) error
+	UpdateAvatarByID(ctx context.Context, userID, url string) (string, error)
+	UpdateProfile(ctx context.Context, userID string, username, avatar *string) (user.User, error)
+	ChangePassword(ctx context.Context, userID, password, keepSessionID string) error
+	ChangeEmail(ctx context.Context, userID, oldEmail, newEmail, revertHash string, expireTime int64) error
+	RevertEmail(ctx context.Context, revertHash string) (user.EmailChange, error)
 }
 type UserRepo struct {
 	userDao *user.Queries
@@ -31,16 +41,13 @@ func NewUserRepo() *UserRepo {
 
 var _ IUserRepo = (*UserRepo)(nil)
 
-func (ur *UserRepo) LoginWithAccount(ctx context.Context, account string, password string) (user.User, error) {
-	u, err := ur.userDao.GetUserByAccountAndPassword(ctx, user.GetUserByAccountAndPasswordParams{
-		Account:  account,
-		Password: password,
-	})
+func (ur *UserRepo) LoginWithAccount(ctx context.Context, account string) (user.User, error) {
+	u, err := ur.userDao.GetUserByAccount(ctx, account)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return user.User{}, ErrUserNotExist
 		}
-		global.Log.Error(err)
+		global.Logger(ctx).Error(err)
 		return user.User{}, ErrDefault
 	}
 	return u, nil
@@ -52,13 +59,29 @@ func (ur *UserRepo) LoginWithEmail(ctx context.Context, email string) (user.User
 		if errors.Is(err, pgx.ErrNoRows) {
 			return user.User{}, ErrUserNotExist
 		}
-		global.Log.Error(err)
+		global.Logger(ctx).Error(err)
 		return user.User{}, ErrDefault
 	}
 	return u, nil
 }
 
-func (ur *UserRepo) Register(ctx context.Context, userID, username, email, account, password string) error {
+func (ur *UserRepo) GetUserByID(ctx context.Context, userID string) (user.User, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return user.User{}, err
+	}
+	u, err := ur.userDao.GetUserByUserID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user.User{}, ErrUserNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return user.User{}, ErrDefault
+	}
+	return u, nil
+}
+
+func (ur *UserRepo) Register(ctx context.Context, userID, username, email, account, password, locale string) error {
 	var userUUID pgtype.UUID
 	if err := userUUID.Scan(userID); err != nil {
 		return err
@@ -74,6 +97,7 @@ func (ur *UserRepo) Register(ctx context.Context, userID, username, email, accou
 		Utime:    time.Now().UnixMilli(),
 		Avatar:   "", // 默认头像，如有需要可传入
 		Role:     1,  // 默认角色
+		Locale:   locale,
 	})
 
 	if err != nil {
@@ -86,19 +110,76 @@ func (ur *UserRepo) Register(ctx context.Context, userID, username, email, accou
 				return ErrEmailIsUsed
 			}
 		}
-		global.Log.Error(err)
+		global.Logger(ctx).Error(err)
 		return ErrDefault
 	}
 	return nil
 }
 
+// ResetPassword 重置密码并递增令牌版本，同时注销该用户的所有会话和刷新令牌
+// 已签发的访问令牌因版本不一致而失效，刷新令牌也无法再换取新令牌
 func (ur *UserRepo) ResetPassword(ctx context.Context, email, newPassword string) error {
-	count, err := ur.userDao.UpdatePasswordByEmail(ctx, user.UpdatePasswordByEmailParams{
+	tx, err := global.DB.Begin(ctx)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	defer tx.Rollback(ctx)
+	userUUID, err := ur.userDao.WithTx(tx).UpdatePasswordByEmail(ctx, user.UpdatePasswordByEmailParams{
 		Email:    email,
 		Password: newPassword,
 	})
 	if err != nil {
-		global.Log.Error(err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if _, err := session.New(tx).RevokeAllSessions(ctx, userUUID); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if _, err := token.New(tx).RevokeAllRefreshTokens(ctx, userUUID); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if err := tx.Commit(ctx); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+// GetAuthState 查询鉴权时需要的用户状态：令牌版本和偏好语言
+func (ur *UserRepo) GetAuthState(ctx context.Context, userID string) (user.GetAuthStateByUserIDRow, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return user.GetAuthStateByUserIDRow{}, ErrUserNotExist
+	}
+	state, err := ur.userDao.GetAuthStateByUserID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user.GetAuthStateByUserIDRow{}, ErrUserNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return user.GetAuthStateByUserIDRow{}, ErrDefault
+	}
+	return state, nil
+}
+
+func (ur *UserRepo) UpdateLocaleByID(ctx context.Context, userID, locale string) error {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return ErrUserNotExist
+	}
+	count, err := ur.userDao.UpdateLocaleByUserID(ctx, user.UpdateLocaleByUserIDParams{
+		UserID: userUUID,
+		Locale: locale,
+		Utime:  time.Now().UnixMilli(),
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
 		return ErrDefault
 	}
 	if count == 0 {
@@ -107,21 +188,237 @@ func (ur *UserRepo) ResetPassword(ctx context.Context, email, newPassword string
 	return nil
 }
 
-func (ur *UserRepo) UpdateAvatarByID(ctx context.Context, userID, url string) error {
+func (ur *UserRepo) UpdatePasswordByID(ctx context.Context, userID, password string) error {
 	var userUUID pgtype.UUID
 	if err := userUUID.Scan(userID); err != nil {
 		return err
 	}
-	count, err := ur.userDao.UpdateAvatarByUserID(ctx, user.UpdateAvatarByUserIDParams{
+	count, err := ur.userDao.UpdatePasswordByUserID(ctx, user.UpdatePasswordByUserIDParams{
+		UserID:   userUUID,
+		Password: password,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if count == 0 {
+		return ErrUserNotExist
+	}
+	return nil
+}
+
+// UpdateAvatarByID 修改头像，返回修改前的头像
+func (ur *UserRepo) UpdateAvatarByID(ctx context.Context, userID, url string) (string, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return "", ErrUserNotExist
+	}
+	old, err := ur.userDao.UpdateAvatarByUserID(ctx, user.UpdateAvatarByUserIDParams{
 		UserID: userUUID,
 		Avatar: url,
+		Utime:  time.Now().UnixMilli(),
 	})
 	if err != nil {
-		global.Log.Error(err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrUserNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return "", ErrDefault
+	}
+	return old, nil
+}
+
+// UpdateProfile 修改用户资料，参数为 nil 的字段保持不变
+func (ur *UserRepo) UpdateProfile(ctx context.Context, userID string, username, avatar *string) (user.User, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return user.User{}, ErrUserNotExist
+	}
+	params := user.UpdateProfileByUserIDParams{
+		UserID: userUUID,
+		Utime:  time.Now().UnixMilli(),
+	}
+	if username != nil {
+		params.Username = pgtype.Text{String: *username, Valid: true}
+	}
+	if avatar != nil {
+		params.Avatar = pgtype.Text{String: *avatar, Valid: true}
+	}
+	u, err := ur.userDao.UpdateProfileByUserID(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user.User{}, ErrUserNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return user.User{}, ErrDefault
+	}
+	return u, nil
+}
+
+// ChangePassword 修改密码并递增令牌版本，同时注销除 keepSessionID 以外的所有会话和刷新令牌
+func (ur *UserRepo) ChangePassword(ctx context.Context, userID, password, keepSessionID string) error {
+	var userUUID, sessionUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return ErrUserNotExist
+	}
+	if err := sessionUUID.Scan(keepSessionID); err != nil {
+		return err
+	}
+	tx, err := global.DB.Begin(ctx)
+	if err != nil {
+		global.Logger(ctx).Error(err)
 		return ErrDefault
 	}
-	if count == 0 {
+	defer tx.Rollback(ctx)
+	_, err = ur.userDao.WithTx(tx).ChangePasswordByUserID(ctx, user.ChangePasswordByUserIDParams{
+		UserID:   userUUID,
+		Password: password,
+		Utime:    time.Now().UnixMilli(),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotExist
+		}
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if _, err := session.New(tx).RevokeOtherSessions(ctx, session.RevokeOtherSessionsParams{
+		UserID:    userUUID,
+		SessionID: sessionUUID,
+	}); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if _, err := token.New(tx).RevokeOtherRefreshTokenFamilies(ctx, token.RevokeOtherRefreshTokenFamiliesParams{
+		UserID:   userUUID,
+		FamilyID: sessionUUID,
+	}); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if err := tx.Commit(ctx); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	return nil
+}
+
+// ChangeEmail 把邮箱从 oldEmail 改为 newEmail，同时保存撤销令牌的摘要
+// 新邮箱已被其他用户使用时返回 ErrEmailIsUsed，邮箱已不是 oldEmail 时返回 ErrUserNotExist
+func (ur *UserRepo) ChangeEmail(ctx context.Context, userID, oldEmail, newEmail, revertHash string, expireTime int64) error {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
 		return ErrUserNotExist
 	}
+	tx, err := global.DB.Begin(ctx)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	defer tx.Rollback(ctx)
+	now := time.Now().UnixMilli()
+	n, err := ur.userDao.WithTx(tx).UpdateEmailByUserID(ctx, user.UpdateEmailByUserIDParams{
+		NewEmail: newEmail,
+		Utime:    now,
+		UserID:   userUUID,
+		OldEmail: oldEmail,
+	})
+	if err != nil {
+		if isEmailUniqueViolation(err) {
+			return ErrEmailIsUsed
+		}
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if n == 0 {
+		return ErrUserNotExist
+	}
+	err = ur.userDao.WithTx(tx).CreateEmailChange(ctx, user.CreateEmailChangeParams{
+		UserID:     userUUID,
+		OldEmail:   oldEmail,
+		NewEmail:   newEmail,
+		RevertHash: revertHash,
+		Ctime:      now,
+		ExpireTime: expireTime,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
+	if err := tx.Commit(ctx); err != nil {
+		global.Logger(ctx).Error(err)
+		return ErrDefault
+	}
 	return nil
 }
+
+// RevertEmail 通过撤销令牌把邮箱改回旧邮箱，递增令牌版本并注销该用户的所有会话和刷新令牌
+// 之后又发生过的修改一并作废，避免攻击者连续修改邮箱后用后续的撤销链接把邮箱再改回去
+// 令牌不存在、已使用或已过期时返回 ErrEmailChangeInvalid
+func (ur *UserRepo) RevertEmail(ctx context.Context, revertHash string) (user.EmailChange, error) {
+	tx, err := global.DB.Begin(ctx)
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return user.EmailChange{}, ErrDefault
+	}
+	defer tx.Rollback(ctx)
+	dao := ur.userDao.WithTx(tx)
+	change, err := dao.GetEmailChangeForUpdate(ctx, revertHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user.EmailChange{}, ErrEmailChangeInvalid
+		}
+		global.Logger(ctx).Error(err)
+		return user.EmailChange{}, ErrDefault
+	}
+	now := time.Now().UnixMilli()
+	if change.Reverted || change.ExpireTime < now {
+		return user.EmailChange{}, ErrEmailChangeInvalid
+	}
+	n, err := dao.RevertEmailByUserID(ctx, user.RevertEmailByUserIDParams{
+		OldEmail: change.OldEmail,
+		Utime:    now,
+		UserID:   change.UserID,
+	})
+	if err != nil {
+		if isEmailUniqueViolation(err) {
+			return user.EmailChange{}, ErrEmailIsUsed
+		}
+		global.Logger(ctx).Error(err)
+		return user.EmailChange{}, ErrDefault
+	}
+	if n == 0 {
+		return user.EmailChange{}, ErrEmailChangeInvalid
+	}
+	if err := dao.MarkEmailChangeReverted(ctx, change.ID); err != nil {
+		global.Logger(ctx).Error(err)
+		return user.EmailChange{}, ErrDefault
+	}
+	err = dao.InvalidateLaterEmailChanges(ctx, user.InvalidateLaterEmailChangesParams{
+		UserID: change.UserID,
+		ID:     change.ID,
+	})
+	if err != nil {
+		global.Logger(ctx).Error(err)
+		return user.EmailChange{}, ErrDefault
+	}
+	if _, err := session.New(tx).RevokeAllSessions(ctx, change.UserID); err != nil {
+		global.Logger(ctx).Error(err)
+		return user.EmailChange{}, ErrDefault
+	}
+	if _, err := token.New(tx).RevokeAllRefreshTokens(ctx, change.UserID); err != nil {
+		global.Logger(ctx).Error(err)
+		return user.EmailChange{}, ErrDefault
+	}
+	if err := tx.Commit(ctx); err != nil {
+		global.Logger(ctx).Error(err)
+		return user.EmailChange{}, ErrDefault
+	}
+	return change, nil
+}
+
+// isEmailUniqueViolation 是否违反了邮箱的唯一约束
+func isEmailUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "user_email_key"
+}
@@ -11,11 +11,60 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const changePasswordByUserID = `-- name: ChangePasswordByUserID :one
+UPDATE "user"
+SET password = $2, token_version = token_version + 1, must_reset_password = FALSE, utime = $3
+WHERE user_id = $1
+RETURNING token_version
+`
+
+type ChangePasswordByUserIDParams struct {
+	UserID   pgtype.UUID
+	Password string
+	Utime    int64
+}
+
+func (q *Queries) ChangePasswordByUserID(ctx context.Context, arg ChangePasswordByUserIDParams) (int32, error) {
+	row := q.db.QueryRow(ctx, changePasswordByUserID, arg.UserID, arg.Password, arg.Utime)
+	var token_version int32
+	err := row.Scan(&token_version)
+	return token_version, err
+}
+
+const createEmailChange = `-- name: CreateEmailChange :exec
+INSERT INTO email_change (
+  user_id, old_email, new_email, revert_hash, ctime, expire_time
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+`
+
+type CreateEmailChangeParams struct {
+	UserID     pgtype.UUID
+	OldEmail   string
+	NewEmail   string
+	RevertHash string
+	Ctime      int64
+	ExpireTime int64
+}
+
+func (q *Queries) CreateEmailChange(ctx context.Context, arg CreateEmailChangeParams) error {
+	_, err := q.db.Exec(ctx, createEmailChange,
+		arg.UserID,
+		arg.OldEmail,
+		arg.NewEmail,
+		arg.RevertHash,
+		arg.Ctime,
+		arg.ExpireTime,
+	)
+	return err
+}
+
 const createUser = `-- name: CreateUser :exec
 INSERT INTO "user" (
-  user_id, ctime, utime, account, password, email, username, avatar, role
+  user_id, ctime, utime, account, password, email, username, avatar, role, locale
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 )
 `
 
@@ -29,6 +78,7 @@ type CreateUserParams struct {
 	Username string
 	Avatar   string
 	Role     int16
+	Locale   string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
@@ -42,22 +92,57 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
 		arg.Username,
 		arg.Avatar,
 		arg.Role,
+		arg.Locale,
 	)
 	return err
 }
 
-const getUserByAccountAndPassword = `-- name: GetUserByAccountAndPassword :one
-SELECT id, user_id, ctime, utime, account, password, email, username, avatar, role FROM "user"
-WHERE account = $1 AND password = $2 LIMIT 1
+const getAuthStateByUserID = `-- name: GetAuthStateByUserID :one
+SELECT token_version, locale FROM "user"
+WHERE user_id = $1 LIMIT 1
 `
 
-type GetUserByAccountAndPasswordParams struct {
-	Account  string
-	Password string
+type GetAuthStateByUserIDRow struct {
+	TokenVersion int32
+	Locale       string
+}
+
+func (q *Queries) GetAuthStateByUserID(ctx context.Context, userID pgtype.UUID) (GetAuthStateByUserIDRow, error) {
+	row := q.db.QueryRow(ctx, getAuthStateByUserID, userID)
+	var i GetAuthStateByUserIDRow
+	err := row.Scan(&i.TokenVersion, &i.Locale)
+	return i, err
+}
+
+const getEmailChangeForUpdate = `-- name: GetEmailChangeForUpdate :one
+SELECT id, user_id, old_email, new_email, revert_hash, ctime, expire_time, reverted FROM email_change
+WHERE revert_hash = $1 LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetEmailChangeForUpdate(ctx context.Context, revertHash string) (EmailChange, error) {
+	row := q.db.QueryRow(ctx, getEmailChangeForUpdate, revertHash)
+	var i EmailChange
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OldEmail,
+		&i.NewEmail,
+		&i.RevertHash,
+		&i.Ctime,
+		&i.ExpireTime,
+		&i.Reverted,
+	)
+	return i, err
 }
 
-func (q *Queries) GetUserByAccountAndPassword(ctx context.Context, arg GetUserByAccountAndPasswordParams) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByAccountAndPassword, arg.Account, arg.Password)
+const getUserByAccount = `-- name: GetUserByAccount :one
+SELECT id, user_id, ctime, utime, account, password, email, username, avatar, role, token_version, locale, deleted_at, disabled, must_reset_password FROM "user"
+WHERE account = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByAccount(ctx context.Context, account string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByAccount, account)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -70,12 +155,17 @@ func (q *Queries) GetUserByAccountAndPassword(ctx context.Context, arg GetUserBy
 		&i.Username,
 		&i.Avatar,
 		&i.Role,
+		&i.TokenVersion,
+		&i.Locale,
+		&i.DeletedAt,
+		&i.Disabled,
+		&i.MustResetPassword,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, user_id, ctime, utime, account, password, email, username, avatar, role FROM "user"
+SELECT id, user_id, ctime, utime, account, password, email, username, avatar, role, token_version, locale, deleted_at, disabled, must_reset_password FROM "user"
 WHERE email = $1 LIMIT 1
 `
 
@@ -93,33 +183,167 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Username,
 		&i.Avatar,
 		&i.Role,
+		&i.TokenVersion,
+		&i.Locale,
+		&i.DeletedAt,
+		&i.Disabled,
+		&i.MustResetPassword,
+	)
+	return i, err
+}
+
+const getUserByUserID = `-- name: GetUserByUserID :one
+SELECT id, user_id, ctime, utime, account, password, email, username, avatar, role, token_version, locale, deleted_at, disabled, must_reset_password FROM "user"
+WHERE user_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByUserID(ctx context.Context, userID pgtype.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByUserID, userID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Ctime,
+		&i.Utime,
+		&i.Account,
+		&i.Password,
+		&i.Email,
+		&i.Username,
+		&i.Avatar,
+		&i.Role,
+		&i.TokenVersion,
+		&i.Locale,
+		&i.DeletedAt,
+		&i.Disabled,
+		&i.MustResetPassword,
 	)
 	return i, err
 }
 
-const updateAvatarByUserID = `-- name: UpdateAvatarByUserID :execrows
+const invalidateLaterEmailChanges = `-- name: InvalidateLaterEmailChanges :exec
+UPDATE email_change
+SET reverted = TRUE
+WHERE user_id = $1 AND id > $2 AND reverted = FALSE
+`
+
+type InvalidateLaterEmailChangesParams struct {
+	UserID pgtype.UUID
+	ID     int64
+}
+
+// 撤销后作废该用户在此之后的修改记录，其撤销链接发往的邮箱可能由攻击者持有
+func (q *Queries) InvalidateLaterEmailChanges(ctx context.Context, arg InvalidateLaterEmailChangesParams) error {
+	_, err := q.db.Exec(ctx, invalidateLaterEmailChanges, arg.UserID, arg.ID)
+	return err
+}
+
+const markEmailChangeReverted = `-- name: MarkEmailChangeReverted :exec
+UPDATE email_change
+SET reverted = TRUE
+WHERE id = $1
+`
+
+func (q *Queries) MarkEmailChangeReverted(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, markEmailChangeReverted, id)
+	return err
+}
+
+const revertEmailByUserID = `-- name: RevertEmailByUserID :execrows
 UPDATE "user"
-SET avatar = $2
-WHERE user_id = $1
+SET email = $1, token_version = token_version + 1, utime = $2
+WHERE user_id = $3
+`
+
+type RevertEmailByUserIDParams struct {
+	OldEmail string
+	Utime    int64
+	UserID   pgtype.UUID
+}
+
+// 改回旧邮箱并递增令牌版本，使修改邮箱的人持有的令牌全部失效
+// 不限制当前邮箱，邮箱被连续修改多次后，最初的撤销链接仍然能把邮箱改回来
+func (q *Queries) RevertEmailByUserID(ctx context.Context, arg RevertEmailByUserIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revertEmailByUserID, arg.OldEmail, arg.Utime, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateAvatarByUserID = `-- name: UpdateAvatarByUserID :one
+UPDATE "user" u
+SET avatar = $1, utime = $2
+FROM (SELECT o.user_id, o.avatar FROM "user" o WHERE o.user_id = $3 FOR UPDATE) old
+WHERE u.user_id = old.user_id
+RETURNING old.avatar
 `
 
 type UpdateAvatarByUserIDParams struct {
-	UserID pgtype.UUID
 	Avatar string
+	Utime  int64
+	UserID pgtype.UUID
+}
+
+// 返回修改前的头像，用于删除旧的头像文件
+func (q *Queries) UpdateAvatarByUserID(ctx context.Context, arg UpdateAvatarByUserIDParams) (string, error) {
+	row := q.db.QueryRow(ctx, updateAvatarByUserID, arg.Avatar, arg.Utime, arg.UserID)
+	var avatar string
+	err := row.Scan(&avatar)
+	return avatar, err
+}
+
+const updateEmailByUserID = `-- name: UpdateEmailByUserID :execrows
+UPDATE "user"
+SET email = $1, utime = $2
+WHERE user_id = $3 AND email = $4
+`
+
+type UpdateEmailByUserIDParams struct {
+	NewEmail string
+	Utime    int64
+	UserID   pgtype.UUID
+	OldEmail string
 }
 
-func (q *Queries) UpdateAvatarByUserID(ctx context.Context, arg UpdateAvatarByUserIDParams) (int64, error) {
-	result, err := q.db.Exec(ctx, updateAvatarByUserID, arg.UserID, arg.Avatar)
+// 只有邮箱仍是 old_email 时才修改，防止并发修改互相覆盖
+func (q *Queries) UpdateEmailByUserID(ctx context.Context, arg UpdateEmailByUserIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateEmailByUserID,
+		arg.NewEmail,
+		arg.Utime,
+		arg.UserID,
+		arg.OldEmail,
+	)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected(), nil
 }
 
-const updatePasswordByEmail = `-- name: UpdatePasswordByEmail :execrows
+const updateLocaleByUserID = `-- name: UpdateLocaleByUserID :execrows
 UPDATE "user"
-SET password = $2
+SET locale = $2, utime = $3
+WHERE user_id = $1
+`
+
+type UpdateLocaleByUserIDParams struct {
+	UserID pgtype.UUID
+	Locale string
+	Utime  int64
+}
+
+func (q *Queries) UpdateLocaleByUserID(ctx context.Context, arg UpdateLocaleByUserIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateLocaleByUserID, arg.UserID, arg.Locale, arg.Utime)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updatePasswordByEmail = `-- name: UpdatePasswordByEmail :one
+UPDATE "user"
+SET password = $2, token_version = token_version + 1, must_reset_password = FALSE
 WHERE email = $1
+RETURNING user_id
 `
 
 type UpdatePasswordByEmailParams struct {
@@ -127,10 +351,73 @@ type UpdatePasswordByEmailParams struct {
 	Password string
 }
 
-func (q *Queries) UpdatePasswordByEmail(ctx context.Context, arg UpdatePasswordByEmailParams) (int64, error) {
-	result, err := q.db.Exec(ctx, updatePasswordByEmail, arg.Email, arg.Password)
+func (q *Queries) UpdatePasswordByEmail(ctx context.Context, arg UpdatePasswordByEmailParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, updatePasswordByEmail, arg.Email, arg.Password)
+	var user_id pgtype.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const updatePasswordByUserID = `-- name: UpdatePasswordByUserID :execrows
+UPDATE "user"
+SET password = $2
+WHERE user_id = $1
+`
+
+type UpdatePasswordByUserIDParams struct {
+	UserID   pgtype.UUID
+	Password string
+}
+
+func (q *Queries) UpdatePasswordByUserID(ctx context.Context, arg UpdatePasswordByUserIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updatePasswordByUserID, arg.UserID, arg.Password)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected(), nil
 }
+
+const updateProfileByUserID = `-- name: UpdateProfileByUserID :one
+UPDATE "user"
+SET username = COALESCE($1, username),
+    avatar = COALESCE($2, avatar),
+    utime = $3
+WHERE user_id = $4
+RETURNING id, user_id, ctime, utime, account, password, email, username, avatar, role, token_version, locale, deleted_at, disabled, must_reset_password
+`
+
+type UpdateProfileByUserIDParams struct {
+	Username pgtype.Text
+	Avatar   pgtype.Text
+	Utime    int64
+	UserID   pgtype.UUID
+}
+
+// 参数为 NULL 的字段保持不变
+func (q *Queries) UpdateProfileByUserID(ctx context.Context, arg UpdateProfileByUserIDParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateProfileByUserID,
+		arg.Username,
+		arg.Avatar,
+		arg.Utime,
+		arg.UserID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Ctime,
+		&i.Utime,
+		&i.Account,
+		&i.Password,
+		&i.Email,
+		&i.Username,
+		&i.Avatar,
+		&i.Role,
+		&i.TokenVersion,
+		&i.Locale,
+		&i.DeletedAt,
+		&i.Disabled,
+		&i.MustResetPassword,
+	)
+	return i, err
+}
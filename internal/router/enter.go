@@ -1,34 +1,105 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"nurture/internal/config"
 	"nurture/internal/dto"
+	"nurture/internal/global"
 	"nurture/internal/handler"
 	manager "nurture/internal/manger"
 	"nurture/internal/middleware"
+	"nurture/internal/pkg/jwtx"
+	"nurture/internal/pkg/metricx"
 	"nurture/internal/pkg/response"
+	"nurture/internal/pkg/storagex"
+	"nurture/internal/pkg/validatex"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 )
 
 // RunServer 启动服务器 路由层
+// HTTP 服务注册到 global.Lifecycle，收到 SIGINT、SIGTERM 或服务异常退出时返回，由调用方统一停止
 func RunServer() {
 	r, err := listen()
 	if err != nil {
 		panic(err.Error())
 	}
-	err = r.Run(fmt.Sprintf("%s:%d", config.Conf.App.Host, config.Conf.App.Port)) // 启动 Gin 服务器
+	errCh := make(chan error, 2)
+	if metrics := config.Conf.Metrics; metrics.Enable && separateMetricsPort() {
+		mux := http.NewServeMux()
+		mux.Handle(metrics.MetricsPath(), metricx.Handler())
+		serve("指标服务", newServer(metrics.Addr(), mux), errCh)
+	}
+	serve("HTTP 服务", newServer(config.Conf.App.Link(), r), errCh)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case <-ctx.Done():
+		global.Log.Info("收到退出信号，开始停止服务")
+	case err := <-errCh:
+		global.Log.Errorf("服务异常退出:%v", err)
+	}
+}
+
+func newServer(addr string, handler http.Handler) *http.Server {
+	read, write, idle := config.Conf.App.ServerTimeouts()
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: read,
+		ReadTimeout:       read,
+		WriteTimeout:      write,
+		IdleTimeout:       idle,
+	}
+}
+
+// serve 监听端口并在后台处理请求，端口监听失败时 panic
+// 停止时 Shutdown 先关闭监听，再等待处理中的请求完成
+func serve(name string, server *http.Server, errCh chan<- error) {
+	ln, err := net.Listen("tcp", server.Addr)
 	if err != nil {
-		panic(err.Error())
+		panic(fmt.Sprintf("%s监听%s失败:%v", name, server.Addr, err))
 	}
+	global.Lifecycle.Add(name, server.Shutdown)
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("%s: %w", name, err)
+		}
+	}()
 }
 
 // listen 配置 Gin 服务器
 func listen() (*gin.Engine, error) {
-	r := gin.Default() // 创建默认的 Gin 引擎
+	r := gin.New() // 访问日志由 AccessLog 中间件记录，不使用 gin 默认的 Logger
+	r.Use(gin.Recovery())
+	// 默认不信任任何代理，否则任意客户端都能通过 X-Forwarded-For 伪造 IP
+	if err := r.SetTrustedProxies(config.Conf.App.TrustedProxies); err != nil {
+		return nil, err
+	}
+	// 注册自定义校验规则和校验错误的翻译
+	validatex.InitValidator()
+	// 指标路由在全局中间件之前注册，抓取请求不记录访问日志、链路和请求指标
+	if config.Conf.Metrics.Enable && !separateMetricsPort() {
+		// 业务端口对外开放，指标没有认证，只在开发环境允许
+		if config.Conf.App.Env != "dev" {
+			return nil, errors.New("metrics on app.port is only allowed in dev, set metrics.port to a separate port")
+		}
+		r.GET(config.Conf.Metrics.MetricsPath(), gin.WrapH(metricx.Handler()))
+	}
 	// 注册全局中间件（例如获取 Trace ID）
 	manager.RequestGlobalMiddleware(r)
+	// 本地存储的文件通过静态路由访问
+	if storage := config.Conf.Storage; storage.Driver == "" || storage.Driver == storagex.DriverLocal {
+		r.Static(storage.Local.URLPrefix, storage.Local.Dir)
+	}
 	// 创建 RouteManager 实例
 	routeManager := manager.NewRouteManager(r)
 	// 注册各业务路由组的具体路由
@@ -36,6 +107,12 @@ func listen() (*gin.Engine, error) {
 	return r, nil
 }
 
+// separateMetricsPort 指标是否单独监听端口
+func separateMetricsPort() bool {
+	port := config.Conf.Metrics.Port
+	return port != 0 && port != config.Conf.App.Port
+}
+
 // registerRoutes 注册各业务路由的具体处理函数
 func registerRoutes(routeManager *manager.RouteManager) {
 
@@ -43,15 +120,68 @@ func registerRoutes(routeManager *manager.RouteManager) {
 		rg.GET("/ping", func(c *gin.Context) {
 			response.Response(c, "pong", nil)
 		})
+		commonHandler := handler.NewCommonHandler()
+		rg.GET("/.well-known/jwks.json", commonHandler.JWKS)
 	})
 
 	routeManager.RegisterUserRoutes(func(rg *gin.RouterGroup) {
 		userHandler := handler.NewUserHandler()
 		rg.POST("/login", middleware.BindJsonMiddleware[dto.LoginReq], userHandler.Login)
 		rg.POST("/register", middleware.BindJsonMiddleware[dto.RegisterReq], userHandler.Register)
-		rg.POST("/code/login", middleware.BindJsonMiddleware[dto.GetCodeReq], userHandler.GetLoginCode)
-		rg.POST("/code/register", middleware.BindJsonMiddleware[dto.GetCodeReq], userHandler.GetRegisterCode)
-		rg.POST("/code/reset", middleware.BindJsonMiddleware[dto.GetCodeReq], userHandler.GetResetCode)
 		rg.POST("/resetPassword", middleware.BindJsonMiddleware[dto.ResetPasswordReq], userHandler.ResetPassword)
+
+		accountHandler := handler.NewAccountHandler()
+		me := rg.Group("/me", middleware.Authentication(jwtx.COMMON_USER))
+		me.GET("", userHandler.GetProfile)
+		me.PATCH("", middleware.BindJsonMiddleware[dto.UpdateProfileReq], userHandler.UpdateProfile)
+		me.DELETE("", middleware.BindJsonMiddleware[dto.DeleteAccountReq], accountHandler.DeleteAccount)
+		me.GET("/export", accountHandler.Export)
+		me.PUT("/password", middleware.BindJsonMiddleware[dto.ChangePasswordReq], userHandler.ChangePassword)
+		me.PUT("/locale", middleware.BindJsonMiddleware[dto.UpdateLocaleReq], userHandler.UpdateLocale)
+		me.POST("/avatar", userHandler.UploadAvatar)
+		// 兼容移到 /me 之前的旧地址
+		rg.PUT("/locale", middleware.Authentication(jwtx.COMMON_USER), middleware.BindJsonMiddleware[dto.UpdateLocaleReq], userHandler.UpdateLocale)
+		me.PUT("/email", middleware.BindJsonMiddleware[dto.ChangeEmailReq], userHandler.ChangeEmail)
+
+		tokenHandler := handler.NewTokenHandler()
+		rg.POST("/token/refresh", middleware.BindJsonMiddleware[dto.RefreshTokenReq], tokenHandler.Refresh)
+		rg.POST("/logout", middleware.BindJsonMiddleware[dto.LogoutReq], tokenHandler.Logout)
+
+		emailHandler := handler.NewEmailHandler()
+		rg.GET("/email/:message_id", middleware.Authentication(jwtx.COMMON_USER), middleware.BindUriMiddleware[dto.EmailStatusReq], emailHandler.GetEmailStatus)
+		// 撤销链接发给旧邮箱，账号可能已被他人控制，因此不要求登录
+		rg.POST("/email/revert", middleware.BindJsonMiddleware[dto.RevertEmailReq], userHandler.RevertEmail)
+
+		sessionHandler := handler.NewSessionHandler()
+		sessions := rg.Group("/sessions", middleware.Authentication(jwtx.COMMON_USER))
+		sessions.GET("", sessionHandler.ListSessions)
+		sessions.DELETE("", sessionHandler.RevokeOtherSessions)
+		sessions.DELETE("/:session_id", middleware.BindUriMiddleware[dto.RevokeSessionReq], sessionHandler.RevokeSession)
+	})
+
+	// 中间件需要在注册路由之前添加才会生效
+	routeManager.RegisterMiddleware("code", middleware.CodeRateLimit)
+	routeManager.RegisterCodeRoutes(func(rg *gin.RouterGroup) {
+		userHandler := handler.NewUserHandler()
+		rg.POST("/login", middleware.BindJsonMiddleware[dto.GetCodeReq], userHandler.GetLoginCode)
+		rg.POST("/register", middleware.BindJsonMiddleware[dto.GetCodeReq], userHandler.GetRegisterCode)
+		rg.POST("/reset", middleware.BindJsonMiddleware[dto.GetCodeReq], userHandler.GetResetCode)
+		rg.POST("/changeEmail", middleware.Authentication(jwtx.COMMON_USER), middleware.UserCodeRateLimit(), middleware.BindJsonMiddleware[dto.GetCodeReq], userHandler.GetChangeEmailCode)
+		accountHandler := handler.NewAccountHandler()
+		rg.POST("/deleteAccount", middleware.Authentication(jwtx.COMMON_USER), middleware.UserCodeRateLimit(), accountHandler.GetDeleteAccountCode)
+	})
+
+	routeManager.RegisterMiddleware("admin", func() gin.HandlerFunc {
+		return middleware.Authentication(jwtx.ADMIN)
+	})
+	routeManager.RegisterAdminRoutes(func(rg *gin.RouterGroup) {
+		adminHandler := handler.NewAdminHandler()
+		users := rg.Group("/users")
+		users.GET("", middleware.BindQueryMiddleware[dto.ListUsersReq], adminHandler.ListUsers)
+		users.GET("/:user_id", middleware.BindUriMiddleware[dto.AdminUserReq], adminHandler.GetUser)
+		users.PUT("/:user_id/role", middleware.BindUriJsonMiddleware[dto.UpdateRoleReq], adminHandler.UpdateRole)
+		users.POST("/:user_id/disable", middleware.BindUriMiddleware[dto.AdminUserReq], adminHandler.DisableUser)
+		users.POST("/:user_id/enable", middleware.BindUriMiddleware[dto.AdminUserReq], adminHandler.EnableUser)
+		users.POST("/:user_id/resetPassword", middleware.BindUriMiddleware[dto.AdminUserReq], adminHandler.RequirePasswordReset)
 	})
 }